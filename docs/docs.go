@@ -0,0 +1,252 @@
+package docs
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type (
+	// A Reference contains the documentation for all definitions found in one or more programs
+	Reference struct {
+		Classes      []*Definition `json:"classes,omitempty"`
+		DefinedTypes []*Definition `json:"defined_types,omitempty"`
+		Functions    []*Definition `json:"functions,omitempty"`
+		Plans        []*Definition `json:"plans,omitempty"`
+		TypeAliases  []*Definition `json:"type_aliases,omitempty"`
+	}
+
+	// A Definition is the documentation of a class, defined type, function, plan, or type alias
+	Definition struct {
+		Name        string       `json:"name"`
+		File        string       `json:"file,omitempty"`
+		Line        int          `json:"line"`
+		Summary     string       `json:"summary,omitempty"`
+		Description string       `json:"description,omitempty"`
+		Inherits    string       `json:"inherits,omitempty"`
+		Parameters  []*Parameter `json:"parameters,omitempty"`
+		ReturnType  string       `json:"return_type,omitempty"`
+		Returns     string       `json:"returns,omitempty"`
+		AliasOf     string       `json:"alias_of,omitempty"`
+		Examples    []*Example   `json:"examples,omitempty"`
+		Tags        []*Tag       `json:"tags,omitempty"`
+	}
+
+	// A Parameter describes a parameter of a definition
+	Parameter struct {
+		Name         string `json:"name"`
+		Type         string `json:"type,omitempty"`
+		Default      string `json:"default,omitempty"`
+		CapturesRest bool   `json:"captures_rest,omitempty"`
+		Description  string `json:"description,omitempty"`
+	}
+
+	// An Example is the title and the code of an @example tag
+	Example struct {
+		Title string `json:"title,omitempty"`
+		Code  string `json:"code"`
+	}
+
+	// A Tag is a tag in a doc comment that has no special meaning, e.g. @since or @api
+	Tag struct {
+		Name string `json:"name"`
+		Text string `json:"text,omitempty"`
+	}
+)
+
+// Extract returns a new Reference that contains the documentation for all definitions in the given program
+func Extract(program *parser.Program) *Reference {
+	r := &Reference{}
+	r.Add(program)
+	return r
+}
+
+// Add adds the documentation for all definitions in the given program to the receiver
+func (r *Reference) Add(program *parser.Program) {
+	for _, d := range program.Definitions() {
+		switch d := d.(type) {
+		case *parser.HostClassDefinition:
+			doc := newDefinition(d, d.Name(), d.Parameters())
+			doc.Inherits = d.ParentClass()
+			r.Classes = append(r.Classes, doc)
+		case *parser.ResourceTypeDefinition:
+			r.DefinedTypes = append(r.DefinedTypes, newDefinition(d, d.Name(), d.Parameters()))
+		case *parser.PlanDefinition:
+			r.Plans = append(r.Plans, newFunctionDefinition(&d.FunctionDefinition))
+		case *parser.FunctionDefinition:
+			r.Functions = append(r.Functions, newFunctionDefinition(d))
+		case *parser.TypeAlias:
+			doc := newDefinition(d, d.Name(), nil)
+			doc.AliasOf = d.Type().String()
+			r.TypeAliases = append(r.TypeAliases, doc)
+		}
+	}
+	for _, defs := range [][]*Definition{r.Classes, r.DefinedTypes, r.Functions, r.Plans, r.TypeAliases} {
+		sort.SliceStable(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	}
+}
+
+func newFunctionDefinition(d *parser.FunctionDefinition) *Definition {
+	doc := newDefinition(d, d.Name(), d.Parameters())
+	if d.ReturnType() != nil {
+		doc.ReturnType = d.ReturnType().String()
+	}
+	return doc
+}
+
+func newDefinition(d parser.Definition, name string, params []parser.Expression) *Definition {
+	doc := &Definition{Name: name, File: d.File(), Line: d.Line()}
+	for _, pe := range params {
+		p := pe.(*parser.Parameter)
+		dp := &Parameter{Name: p.Name(), CapturesRest: p.CapturesRest()}
+		if p.Type() != nil {
+			dp.Type = p.Type().String()
+		}
+		if p.Value() != nil {
+			dp.Default = p.Value().String()
+		}
+		doc.Parameters = append(doc.Parameters, dp)
+	}
+	doc.parseComment(leadingComment(d))
+	return doc
+}
+
+// leadingComment returns the lines of the comment that immediately precedes the given definition. The
+// leading '#' and one space is stripped from each line.
+func leadingComment(d parser.Definition) []string {
+	source := d.Locator().String()
+	start := d.ByteOffset()
+
+	// Type aliases start at their name so the 'type' keyword must be skipped
+	before := strings.TrimRight(source[:start], " \t")
+	if _, ok := d.(*parser.TypeAlias); ok && strings.HasSuffix(before, `type`) {
+		before = strings.TrimRight(before[:len(before)-4], " \t")
+	}
+
+	// Only consider the comment when the definition is first on its line
+	nl := strings.LastIndexByte(before, '\n')
+	if strings.TrimSpace(before[nl+1:]) != `` {
+		return nil
+	}
+
+	var lines []string
+	for nl >= 0 {
+		before = before[:nl]
+		nl = strings.LastIndexByte(before, '\n')
+		line := strings.TrimSpace(before[nl+1:])
+		if !strings.HasPrefix(line, `#`) {
+			break
+		}
+		line = strings.TrimPrefix(line[1:], ` `)
+		lines = append(lines, line)
+	}
+
+	// Lines were collected in reverse order
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+// parseComment parses the lines of a doc comment. Lines that precede the first tag form the
+// description. Tags are continued by the lines that follow them as long as those lines are
+// indented.
+func (doc *Definition) parseComment(lines []string) {
+	description := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		i++
+		if !strings.HasPrefix(line, `@`) {
+			description = append(description, line)
+			continue
+		}
+
+		var cont []string
+		for ; i < len(lines) && (lines[i] == `` || lines[i][0] == ' ' || lines[i][0] == '\t'); i++ {
+			cont = append(cont, lines[i])
+		}
+		// Trailing empty lines belong to whatever follows
+		for len(cont) > 0 && strings.TrimSpace(cont[len(cont)-1]) == `` {
+			cont = cont[:len(cont)-1]
+			i--
+		}
+
+		tag, text := splitWord(line[1:])
+		switch tag {
+		case `summary`:
+			doc.Summary = joinText(text, cont)
+		case `param`:
+			name, desc := splitWord(text)
+			doc.describeParameter(strings.TrimPrefix(name, `$`), joinText(desc, cont))
+		case `return`, `returns`:
+			doc.Returns = joinText(stripTypeSpec(text), cont)
+		case `example`:
+			doc.Examples = append(doc.Examples, &Example{Title: text, Code: strings.Join(unindent(cont), "\n")})
+		default:
+			doc.Tags = append(doc.Tags, &Tag{Name: tag, Text: joinText(text, cont)})
+		}
+	}
+	doc.Description = strings.TrimSpace(strings.Join(description, "\n"))
+}
+
+func (doc *Definition) describeParameter(name, description string) {
+	for _, p := range doc.Parameters {
+		if p.Name == name {
+			p.Description = description
+			return
+		}
+	}
+}
+
+// splitWord splits the given string into its first word and the trimmed remainder
+func splitWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	if ix := strings.IndexAny(s, " \t"); ix > 0 {
+		return s[:ix], strings.TrimSpace(s[ix:])
+	}
+	return s, ``
+}
+
+// stripTypeSpec strips a leading YARD type specification such as [String] from the given text
+func stripTypeSpec(s string) string {
+	if strings.HasPrefix(s, `[`) {
+		if ix := strings.IndexByte(s, ']'); ix > 0 {
+			return strings.TrimSpace(s[ix+1:])
+		}
+	}
+	return s
+}
+
+func joinText(first string, cont []string) string {
+	parts := make([]string, 0, len(cont)+1)
+	if first != `` {
+		parts = append(parts, first)
+	}
+	for _, c := range cont {
+		parts = append(parts, strings.TrimSpace(c))
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n"))
+}
+
+// unindent removes the indentation that is common to all non empty lines
+func unindent(lines []string) []string {
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == `` {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	result := make([]string, len(lines))
+	for i, l := range lines {
+		if len(l) >= indent && indent > 0 {
+			l = l[indent:]
+		}
+		result[i] = strings.TrimRight(l, " \t")
+	}
+	return result
+}
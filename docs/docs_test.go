@@ -0,0 +1,181 @@
+package docs
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/json"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+const source = `
+# Installs the foo service.
+#
+# @summary Manages foo
+#
+# @param ensure
+#   Whether foo should be
+#   present.
+# @param port The port to listen on
+#
+# @example Basic usage
+#   class { 'foo':
+#     port => 8080,
+#   }
+#
+# @since 1.0.0
+class foo(Enum[present, absent] $ensure = present, Integer $port = 80) inherits foo::params {
+}
+
+# Adds one
+# @param x the value
+# @return [Integer] the value plus one
+function foo::inc(Integer $x) >> Integer { $x + 1 }
+
+$x = 1 # not a doc comment
+define foo::bar(*$rest) {}
+
+# A port number
+type Foo::Port = Integer[1, 65535]
+
+# Deploys foo
+plan foo::deploy(TargetSpec $nodes) {}
+`
+
+func TestExtract(t *testing.T) {
+	ref := extract(t, source)
+
+	expectDefinition(t, ref.Classes, &Definition{
+		Name:        `foo`,
+		File:        `test.pp`,
+		Line:        16,
+		Summary:     `Manages foo`,
+		Description: `Installs the foo service.`,
+		Inherits:    `foo::params`,
+		Parameters: []*Parameter{
+			{Name: `ensure`, Type: `Enum[present, absent]`, Default: `present`, Description: "Whether foo should be\npresent."},
+			{Name: `port`, Type: `Integer`, Default: `80`, Description: `The port to listen on`}},
+		Examples: []*Example{{Title: `Basic usage`, Code: "class { 'foo':\n  port => 8080,\n}"}},
+		Tags:     []*Tag{{Name: `since`, Text: `1.0.0`}}})
+
+	expectDefinition(t, ref.Functions, &Definition{
+		Name:        `foo::inc`,
+		File:        `test.pp`,
+		Line:        22,
+		Description: `Adds one`,
+		Parameters:  []*Parameter{{Name: `x`, Type: `Integer`, Description: `the value`}},
+		ReturnType:  `Integer`,
+		Returns:     `the value plus one`})
+
+	expectDefinition(t, ref.DefinedTypes, &Definition{
+		Name:       `foo::bar`,
+		File:       `test.pp`,
+		Line:       25,
+		Parameters: []*Parameter{{Name: `rest`, CapturesRest: true}}})
+
+	expectDefinition(t, ref.TypeAliases, &Definition{
+		Name:        `Foo::Port`,
+		File:        `test.pp`,
+		Line:        28,
+		Description: `A port number`,
+		AliasOf:     `Integer[1, 65535]`})
+
+	expectDefinition(t, ref.Plans, &Definition{
+		Name:        `foo::deploy`,
+		File:        `test.pp`,
+		Line:        31,
+		Description: `Deploys foo`,
+		Parameters:  []*Parameter{{Name: `nodes`, Type: `TargetSpec`}}})
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	ref := extract(t, issue.Unindent(`
+    # Adds one
+    # @param x the value
+    # @return the value plus one
+    # @example Increment
+    #   foo::inc(1)
+    # @since 1.0.0
+    function foo::inc(Integer $x = 0) >> Integer { $x + 1 }
+    `))
+
+	b := bytes.NewBufferString(``)
+	if err := ref.WriteMarkdown(b); err != nil {
+		t.Fatal(err)
+	}
+	expected := issue.Unindent(`
+    # Reference
+
+    ## Table of Contents
+
+    ### Functions
+
+    * [` + "`foo::inc`" + `](#foo--inc): Adds one
+
+    ## Functions
+
+    ### <a name="foo--inc"></a>` + "`foo::inc`" + `
+
+    Defined in ` + "`test.pp`" + `, line 7
+
+    Adds one
+
+    * **Since** 1.0.0
+
+    #### Examples
+
+    ##### Increment
+
+    ` + "```puppet" + `
+    foo::inc(1)
+    ` + "```" + `
+
+    #### Parameters
+
+    The following parameters are available in the ` + "`foo::inc`" + ` function:
+
+    ##### ` + "`x`" + `
+
+    Data type: ` + "`Integer`" + `
+
+    the value
+
+    Default value: ` + "`0`" + `
+
+    #### Returns
+
+    ` + "`Integer`" + ` the value plus one
+
+    `)
+	if actual := b.String(); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func extract(t *testing.T, source string) *Reference {
+	t.Helper()
+	expr, err := parser.CreateParser(parser.TasksEnabled).Parse(`test.pp`, strings.TrimPrefix(source, "\n"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Extract(expr.(*parser.Program))
+}
+
+func expectDefinition(t *testing.T, defs []*Definition, expected *Definition) {
+	t.Helper()
+	if len(defs) != 1 {
+		t.Fatalf(`expected one definition, got %d`, len(defs))
+	}
+	if !reflect.DeepEqual(defs[0], expected) {
+		t.Errorf("expected %s, got %s", toString(expected), toString(defs[0]))
+	}
+}
+
+func toString(d *Definition) string {
+	b := bytes.NewBufferString(``)
+	json.ToJson(d, b)
+	return b.String()
+}
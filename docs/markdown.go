@@ -0,0 +1,151 @@
+package docs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type section struct {
+	title string
+	kind  string
+	defs  []*Definition
+}
+
+func (r *Reference) sections() []*section {
+	return []*section{
+		{`Classes`, `class`, r.Classes},
+		{`Defined types`, `defined type`, r.DefinedTypes},
+		{`Functions`, `function`, r.Functions},
+		{`Plans`, `plan`, r.Plans},
+		{`Data type aliases`, `type alias`, r.TypeAliases},
+	}
+}
+
+// WriteMarkdown writes the receiver as a Markdown document to the given writer
+func (r *Reference) WriteMarkdown(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	b.WriteString("# Reference\n\n")
+
+	sections := r.sections()
+	b.WriteString("## Table of Contents\n\n")
+	for _, s := range sections {
+		if len(s.defs) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "### %s\n\n", s.title)
+		for _, d := range s.defs {
+			fmt.Fprintf(b, "* [`%s`](#%s)", d.Name, anchor(d.Name))
+			if summary := d.summary(); summary != `` {
+				fmt.Fprintf(b, ": %s", summary)
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteByte('\n')
+	}
+
+	for _, s := range sections {
+		if len(s.defs) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "## %s\n\n", s.title)
+		for _, d := range s.defs {
+			d.writeMarkdown(b, s.kind)
+		}
+	}
+	return b.Flush()
+}
+
+func (d *Definition) writeMarkdown(b *bufio.Writer, kind string) {
+	fmt.Fprintf(b, "### <a name=\"%s\"></a>`%s`\n\n", anchor(d.Name), d.Name)
+	if d.File != `` {
+		fmt.Fprintf(b, "Defined in `%s`, line %d\n\n", d.File, d.Line)
+	}
+	if d.Summary != `` {
+		fmt.Fprintf(b, "%s\n\n", d.Summary)
+	}
+	if d.Description != `` {
+		fmt.Fprintf(b, "%s\n\n", d.Description)
+	}
+	if d.Inherits != `` {
+		fmt.Fprintf(b, "Inherits `%s`\n\n", d.Inherits)
+	}
+	if d.AliasOf != `` {
+		fmt.Fprintf(b, "Alias of `%s`\n\n", d.AliasOf)
+	}
+	for _, t := range d.Tags {
+		fmt.Fprintf(b, "* **%s** %s\n", upperFirst(t.Name), t.Text)
+	}
+	if len(d.Tags) > 0 {
+		b.WriteByte('\n')
+	}
+
+	if len(d.Examples) > 0 {
+		b.WriteString("#### Examples\n\n")
+		for _, e := range d.Examples {
+			if e.Title != `` {
+				fmt.Fprintf(b, "##### %s\n\n", e.Title)
+			}
+			fmt.Fprintf(b, "```puppet\n%s\n```\n\n", e.Code)
+		}
+	}
+
+	if len(d.Parameters) > 0 {
+		b.WriteString("#### Parameters\n\n")
+		fmt.Fprintf(b, "The following parameters are available in the `%s` %s:\n\n", d.Name, kind)
+		for _, p := range d.Parameters {
+			name := p.Name
+			if p.CapturesRest {
+				name = `*` + name
+			}
+			fmt.Fprintf(b, "##### `%s`\n\n", name)
+			if p.Type != `` {
+				fmt.Fprintf(b, "Data type: `%s`\n\n", p.Type)
+			}
+			if p.Description != `` {
+				fmt.Fprintf(b, "%s\n\n", p.Description)
+			}
+			if p.Default != `` {
+				fmt.Fprintf(b, "Default value: `%s`\n\n", p.Default)
+			}
+		}
+	}
+
+	if d.ReturnType != `` || d.Returns != `` {
+		b.WriteString("#### Returns\n\n")
+		if d.ReturnType != `` {
+			fmt.Fprintf(b, "`%s`", d.ReturnType)
+			if d.Returns != `` {
+				b.WriteByte(' ')
+			}
+		}
+		fmt.Fprintf(b, "%s\n\n", d.Returns)
+	}
+}
+
+// summary returns the summary of the receiver or, when no summary is given, the first line of its description
+func (d *Definition) summary() string {
+	if d.Summary != `` {
+		return d.Summary
+	}
+	if ix := strings.IndexByte(d.Description, '\n'); ix >= 0 {
+		return d.Description[:ix]
+	}
+	return d.Description
+}
+
+// upperFirst returns the given string with its first rune in upper case
+func upperFirst(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func anchor(name string) string {
+	return strings.Replace(name, `::`, `--`, -1)
+}
@@ -0,0 +1,23 @@
+package epp
+
+import (
+	"github.com/lyraproj/issue/issue"
+)
+
+const (
+	EppMissingParameter      = `EPP_MISSING_PARAMETER`
+	EppNotTemplate           = `EPP_NOT_TEMPLATE`
+	EppParameterTypeMismatch = `EPP_PARAMETER_TYPE_MISMATCH`
+	EppUnknownParameter      = `EPP_UNKNOWN_PARAMETER`
+)
+
+func init() {
+	issue.Hard(EppMissingParameter, `The template expects a value for parameter '%{name}'`)
+
+	issue.Hard2(EppNotTemplate, `%{expression} is not an EPP template. Was it parsed in EPP mode?`,
+		issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard(EppParameterTypeMismatch, `The template parameter '%{name}' expects a value of type %{expected}, got %{actual}`)
+
+	issue.Hard(EppUnknownParameter, `The template has no parameter named '%{name}'`)
+}
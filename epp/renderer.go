@@ -0,0 +1,117 @@
+package epp
+
+import (
+	"sort"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/eval"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// RenderString parses the given EPP source and renders it using the given parameters
+func RenderString(filename, source string, params map[string]interface{}) (string, error) {
+	template, err := parser.CreateParser(parser.EppMode).Parse(filename, source, false)
+	if err != nil {
+		return ``, err
+	}
+	return Render(template, params)
+}
+
+// Render renders a parsed EPP template using the given parameters and a new evaluator
+func Render(template parser.Expression, params map[string]interface{}) (string, error) {
+	return RenderWith(eval.NewEvaluator(), template, params)
+}
+
+// RenderWith renders a parsed EPP template using the given parameters and evaluator. The evaluator
+// provides the functions that are available to the template. Parameter values are converted using
+// eval.ToValue. An error is returned when a value cannot be converted.
+//
+// When the template declares parameters, each given parameter must match a declared parameter and
+// have a value of the declared type. Declared parameters that are not given will get their default
+// value. A template that doesn't declare parameters can use all given parameters as variables.
+func RenderWith(ev *eval.Evaluator, template parser.Expression, params map[string]interface{}) (string, error) {
+	lambda, ok := templateLambda(template)
+	if !ok {
+		return ``, issue.NewReported(EppNotTemplate, issue.SeverityError, issue.H{`expression`: template}, template)
+	}
+	if err := ev.Load(template); err != nil {
+		return ``, err
+	}
+
+	vars := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		value, err := eval.ToValue(v)
+		if err != nil {
+			return ``, err
+		}
+		vars[k] = value
+	}
+	epp := lambda.Body().(*parser.EppExpression)
+	if epp.ParametersSpecified() {
+		var err error
+		if vars, err = bindParameters(ev, lambda, vars); err != nil {
+			return ``, err
+		}
+	}
+	return ev.RenderWith(epp, vars)
+}
+
+func templateLambda(template parser.Expression) (*parser.LambdaExpression, bool) {
+	if p, ok := template.(*parser.Program); ok {
+		template = p.Body()
+	}
+	if l, ok := template.(*parser.LambdaExpression); ok {
+		if _, ok = l.Body().(*parser.EppExpression); ok {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+func bindParameters(ev *eval.Evaluator, lambda *parser.LambdaExpression, params map[string]interface{}) (map[string]interface{}, error) {
+	declared := lambda.Parameters()
+	names := make(map[string]bool, len(declared))
+	for _, pe := range declared {
+		names[pe.(*parser.Parameter).Name()] = true
+	}
+
+	unknown := make([]string, 0)
+	for name := range params {
+		if !names[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, issue.NewReported(EppUnknownParameter, issue.SeverityError, issue.H{`name`: unknown[0]}, lambda)
+	}
+
+	vars := make(map[string]interface{}, len(declared))
+	for _, pe := range declared {
+		p := pe.(*parser.Parameter)
+		value, ok := params[p.Name()]
+		if !ok {
+			if p.Value() == nil {
+				return nil, issue.NewReported(EppMissingParameter, issue.SeverityError, issue.H{`name`: p.Name()}, p)
+			}
+			// Defaults may refer to parameters declared before them
+			var err error
+			if value, err = ev.EvaluateWith(p.Value(), vars); err != nil {
+				return nil, err
+			}
+		}
+		if p.Type() != nil {
+			t, err := literal.ResolveType(p.Type(), ev.TypeAliases())
+			if err != nil {
+				return nil, err
+			}
+			if !t.IsInstance(value) {
+				return nil, issue.NewReported(EppParameterTypeMismatch, issue.SeverityError,
+					issue.H{`name`: p.Name(), `expected`: t.String(), `actual`: literal.TypeString(value)}, p)
+			}
+		}
+		vars[p.Name()] = value
+	}
+	return vars, nil
+}
@@ -0,0 +1,96 @@
+package epp
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/eval"
+)
+
+func TestRenderParameters(t *testing.T) {
+	template := issue.Unindent(`
+    <%- | String $host,
+          Integer $port = 80,
+          String $url = "http://${host}:${port}",
+          Optional[Array[String]] $aliases = undef
+    | -%>
+    host: <%= $host %>
+    url: <%= $url %>
+    <% if $aliases { -%>
+    <%   $aliases.each |$a| { -%>
+    alias: <%= $a %>
+    <%   } -%>
+    <% } -%>
+    done`)
+
+	expectRender(t, template, map[string]interface{}{`host`: `example.com`},
+		issue.Unindent(`
+    host: example.com
+    url: http://example.com:80
+    done`))
+
+	expectRender(t, template, map[string]interface{}{`host`: `example.com`, `port`: 8080, `aliases`: []string{`a`, `b`}},
+		issue.Unindent(`
+    host: example.com
+    url: http://example.com:8080
+    alias: a
+    alias: b
+    done`))
+
+	expectRenderError(t, template, map[string]interface{}{}, EppMissingParameter)
+	expectRenderError(t, template, map[string]interface{}{`host`: `x`, `prot`: 8080}, EppUnknownParameter)
+	expectRenderError(t, template, map[string]interface{}{`host`: `x`, `port`: `80`}, EppParameterTypeMismatch)
+}
+
+func TestRenderWithoutParameters(t *testing.T) {
+	expectRender(t, "<% $x = $a + 1 -%>\nvalue: <%= $x %>, <%# comment %>${x}\n", map[string]interface{}{`a`: 1},
+		"value: 2, ${x}\n")
+	expectRender(t, "just text", nil, "just text")
+}
+
+func TestRenderTrim(t *testing.T) {
+	expectRender(t, "a\n  <%- if true { -%>\n  b\n  <%- } -%>\nc", nil, "a\n  b\nc")
+}
+
+func TestRenderGoValues(t *testing.T) {
+	template := "<%= $a %> <%= $h %> <%= $u + 1 %> <%= $p %>"
+	port := 8080
+	expectRender(t, template, map[string]interface{}{
+		`a`: []int{1, 2},
+		`h`: map[string]uint8{`b`: 2, `a`: 1},
+		`u`: uint32(41),
+		`p`: &port,
+	}, `[1, 2] {'a' => 1, 'b' => 2} 42 8080`)
+
+	expectRenderError(t, template, map[string]interface{}{`a`: struct{}{}}, eval.EvalUnsupportedValue)
+	expectRenderError(t, template, map[string]interface{}{`a`: []interface{}{func() {}}}, eval.EvalUnsupportedValue)
+	expectRenderError(t, template, map[string]interface{}{`u`: uint64(1 << 63)}, eval.EvalValueOutOfRange)
+}
+
+func TestRenderErrors(t *testing.T) {
+	expectRenderError(t, "<%= $y %>", nil, eval.EvalUnknownVariable)
+	expectRenderError(t, "<% notify { 'x': } %>", nil, `VALIDATE_CATALOG_OPERATION_NOT_SUPPORTED`)
+}
+
+func expectRender(t *testing.T, template string, params map[string]interface{}, expected string) {
+	t.Helper()
+	result, err := RenderString(`test.epp`, template, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
+
+func expectRenderError(t *testing.T, template string, params map[string]interface{}, code issue.Code) {
+	t.Helper()
+	_, err := RenderString(`test.epp`, template, params)
+	if err == nil {
+		t.Errorf(`expected %s, got no error`, code)
+		return
+	}
+	if ri, ok := err.(issue.Reported); !ok || ri.Code() != code {
+		t.Errorf(`expected %s, got %s`, code, err.Error())
+	}
+}
@@ -0,0 +1,794 @@
+package eval
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/validator"
+)
+
+type (
+	// A Lambda is a block of code that has been passed to a function
+	Lambda interface {
+		// Call calls the lambda with the given arguments. Errors are propagated by panicking so this
+		// method must only be called from within a Function
+		Call(args ...interface{}) interface{}
+
+		// ParameterCount returns the number of parameters declared by the lambda
+		ParameterCount() int
+	}
+
+	// A Function is a function implemented in Go that can be called from Puppet code. The block is nil
+	// unless the call was made with a lambda. An error returned from the function is reported at the
+	// location of the call.
+	Function func(args []interface{}, block Lambda) (interface{}, error)
+
+	// An Evaluator evaluates the subset of the Puppet language that doesn't require a catalog, i.e.
+	// variables, operators, strings, conditionals, lambdas, and calls to functions. Functions can be
+	// written in Puppet using function definitions or in Go using AddFunction.
+	//
+	// Values are represented in the same way as in the literal package.
+	Evaluator struct {
+		functions   map[string]Function
+		definitions map[string]*parser.FunctionDefinition
+		aliases     map[string]parser.Expression
+		global      *scope
+		output      *bytes.Buffer
+	}
+
+	scope struct {
+		parent *scope
+		vars   map[string]interface{}
+	}
+
+	closure struct {
+		ev     *Evaluator
+		lambda *parser.LambdaExpression
+		scope  *scope
+	}
+
+	returnSignal struct {
+		value interface{}
+		call  parser.Expression
+	}
+
+	nextSignal struct {
+		value interface{}
+		call  parser.Expression
+	}
+
+	breakSignal struct {
+		call parser.Expression
+	}
+)
+
+// NewEvaluator creates an Evaluator with an empty global scope and the built-in functions
+func NewEvaluator() *Evaluator {
+	ev := &Evaluator{
+		functions:   make(map[string]Function, len(builtins)),
+		definitions: make(map[string]*parser.FunctionDefinition),
+		aliases:     make(map[string]parser.Expression),
+		global:      newScope(nil),
+	}
+	for name, f := range builtins {
+		ev.functions[name] = f
+	}
+	return ev
+}
+
+// AddFunction adds a function implemented in Go. It will replace any existing function
+// with the same name.
+func (ev *Evaluator) AddFunction(name string, f Function) {
+	ev.functions[strings.ToLower(name)] = f
+}
+
+// SetVariable assigns a value to a variable in the global scope
+func (ev *Evaluator) SetVariable(name string, value interface{}) {
+	ev.global.vars[name] = value
+}
+
+// TypeAliases returns the type alias declarations that have been loaded by the receiver, keyed by
+// lower case name
+func (ev *Evaluator) TypeAliases() map[string]parser.Expression {
+	return ev.aliases
+}
+
+// Load validates the given expression and makes the function definitions and type aliases that it
+// contains available to subsequent calls. The expression is validated using the tasks validator which
+// means that catalog operations such as resource expressions are refused.
+func (ev *Evaluator) Load(e parser.Expression) error {
+	v := validator.ValidateTasks(e)
+	for _, i := range v.Issues() {
+		if i.Severity() == issue.SeverityError {
+			return i
+		}
+	}
+	if p, ok := e.(*parser.Program); ok {
+		for _, d := range p.Definitions() {
+			switch d := d.(type) {
+			case *parser.FunctionDefinition:
+				ev.definitions[normalizeName(d.Name())] = d
+			case *parser.TypeAlias:
+				ev.aliases[normalizeName(d.Name())] = d.Type()
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate loads the given expression and then evaluates it in the global scope.
+func (ev *Evaluator) Evaluate(e parser.Expression) (interface{}, error) {
+	if err := ev.Load(e); err != nil {
+		return nil, err
+	}
+	return ev.protect(func() interface{} { return ev.evalTop(e, ev.global) })
+}
+
+// EvaluateWith evaluates the given expression in a new local scope that contains the given variables.
+// The expression is not validated.
+func (ev *Evaluator) EvaluateWith(e parser.Expression, vars map[string]interface{}) (interface{}, error) {
+	s := newScope(ev.global)
+	for k, v := range vars {
+		s.vars[k] = v
+	}
+	return ev.protect(func() interface{} { return ev.evalTop(e, s) })
+}
+
+// RenderWith evaluates the given expression in the same way as EvaluateWith and returns the text that
+// was produced by the render expressions of an EPP template.
+func (ev *Evaluator) RenderWith(e parser.Expression, vars map[string]interface{}) (string, error) {
+	saved := ev.output
+	ev.output = bytes.NewBufferString(``)
+	defer func() { ev.output = saved }()
+	if _, err := ev.EvaluateWith(e, vars); err != nil {
+		return ``, err
+	}
+	return ev.output.String(), nil
+}
+
+// Call calls the function with the given name. The function is either a function implemented in Go or
+// a function definition that has been loaded by the receiver.
+func (ev *Evaluator) Call(name string, args ...interface{}) (interface{}, error) {
+	return ev.protect(func() interface{} { return ev.callFunction(name, args, nil, nil) })
+}
+
+func (ev *Evaluator) protect(f func() interface{}) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ri, ok := r.(issue.Reported); ok {
+				err = ri
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	value = f()
+	return
+}
+
+// evalTop evaluates an expression and converts control signals that escape it into errors
+func (ev *Evaluator) evalTop(e parser.Expression, s *scope) (value interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r := r.(type) {
+			case *returnSignal:
+				// A return at the top level ends the evaluation
+				value = r.value
+			case *nextSignal:
+				panic(evalError(EvalIllegalNext, issue.NoArgs, r.call))
+			case *breakSignal:
+				panic(evalError(EvalIllegalBreak, issue.NoArgs, r.call))
+			default:
+				panic(r)
+			}
+		}
+	}()
+	return ev.eval(e, s)
+}
+
+func (ev *Evaluator) eval(e parser.Expression, s *scope) interface{} {
+	switch e := e.(type) {
+	case *parser.Program:
+		return ev.eval(e.Body(), s)
+	case *parser.BlockExpression:
+		var value interface{}
+		for _, stmt := range e.Statements() {
+			value = ev.eval(stmt, s)
+		}
+		return value
+	case *parser.Nop, *parser.FunctionDefinition, *parser.TypeAlias:
+		return nil
+	case *parser.ParenthesizedExpression:
+		return ev.eval(e.Expr(), s)
+	case *parser.EppExpression:
+		return ev.eval(e.Body(), s)
+	case *parser.RenderStringExpression:
+		if ev.output != nil {
+			ev.output.WriteString(e.Value().(string))
+		}
+		return nil
+	case *parser.RenderExpression:
+		value := ev.eval(e.Expr(), s)
+		if ev.output != nil {
+			ev.output.WriteString(literal.ToString(value))
+		}
+		return nil
+	case *parser.AssignmentExpression:
+		return ev.evalAssignment(e, s)
+	case *parser.VariableExpression:
+		return ev.evalVariable(e, s)
+	case *parser.ConcatenatedString:
+		b := bytes.NewBufferString(``)
+		for _, segment := range e.Segments() {
+			b.WriteString(literal.ToString(ev.eval(segment, s)))
+		}
+		return b.String()
+	case *parser.TextExpression:
+		return literal.ToString(ev.eval(e.Expr(), s))
+	case *parser.HeredocExpression:
+		return ev.eval(e.Text(), s)
+	case *parser.LiteralList:
+		return ev.evalList(e.Elements(), s)
+	case *parser.LiteralHash:
+		entries := e.Entries()
+		result := literal.NewHash(len(entries))
+		for _, entry := range entries {
+			ke := entry.(*parser.KeyedEntry)
+			key := ev.eval(ke.Key(), s)
+			switch key.(type) {
+			case []interface{}, *literal.Hash:
+				panic(evalError(literal.LiteralIllegalHashKey, issue.H{`type`: literal.TypeName(key)}, ke.Key()))
+			}
+			result.Put(key, ev.eval(ke.Value(), s))
+		}
+		return result
+	case *parser.ArithmeticExpression:
+		return check(literal.Arithmetic(e.Operator(), ev.eval(e.Lhs(), s), ev.eval(e.Rhs(), s), e))
+	case *parser.ComparisonExpression:
+		return check(literal.Compare(e.Operator(), ev.eval(e.Lhs(), s), ev.eval(e.Rhs(), s), e))
+	case *parser.MatchExpression:
+		return ev.evalMatch(e, s)
+	case *parser.InExpression:
+		return literal.In(ev.eval(e.Lhs(), s), ev.eval(e.Rhs(), s))
+	case *parser.AndExpression:
+		return literal.IsTruthy(ev.eval(e.Lhs(), s)) && literal.IsTruthy(ev.eval(e.Rhs(), s))
+	case *parser.OrExpression:
+		return literal.IsTruthy(ev.eval(e.Lhs(), s)) || literal.IsTruthy(ev.eval(e.Rhs(), s))
+	case *parser.NotExpression:
+		return !literal.IsTruthy(ev.eval(e.Expr(), s))
+	case *parser.UnaryMinusExpression:
+		return check(literal.Negate(ev.eval(e.Expr(), s), e))
+	case *parser.AccessExpression:
+		if _, ok := e.Operand().(*parser.QualifiedReference); ok {
+			return ev.resolveType(e)
+		}
+		return check(literal.Access(ev.eval(e.Operand(), s), ev.evalList(e.Keys(), s), e))
+	case *parser.QualifiedReference:
+		return ev.resolveType(e)
+	case *parser.RegexpExpression:
+		return ev.compileRegexp(e.Value().(string), e)
+	case *parser.IfExpression:
+		if literal.IsTruthy(ev.eval(e.Test(), s)) {
+			return ev.eval(e.Then(), s)
+		}
+		return ev.evalElse(e.Else(), s)
+	case *parser.UnlessExpression:
+		if !literal.IsTruthy(ev.eval(e.Test(), s)) {
+			return ev.eval(e.Then(), s)
+		}
+		return ev.evalElse(e.Else(), s)
+	case *parser.CaseExpression:
+		return ev.evalCase(e, s)
+	case *parser.SelectorExpression:
+		return ev.evalSelector(e, s)
+	case *parser.CallNamedFunctionExpression:
+		name := e.Functor().(parser.NameExpression).Name()
+		return ev.callFunction(name, ev.evalList(e.Arguments(), s), ev.closure(e.Lambda(), s), e)
+	case *parser.CallMethodExpression:
+		if na, ok := e.Functor().(*parser.NamedAccessExpression); ok {
+			if qn, ok := na.Rhs().(*parser.QualifiedName); ok {
+				args := append([]interface{}{ev.eval(na.Lhs(), s)}, ev.evalList(e.Arguments(), s)...)
+				return ev.callFunction(qn.Name(), args, ev.closure(e.Lambda(), s), e)
+			}
+		}
+	case *parser.QualifiedName, *parser.ReservedWord:
+		// Bare words are strings
+		return e.(parser.LiteralValue).Value()
+	case parser.LiteralValue:
+		return e.Value()
+	}
+	panic(evalError(EvalUnsupportedExpression, issue.H{`expression`: e}, e))
+}
+
+func (ev *Evaluator) evalElse(e parser.Expression, s *scope) interface{} {
+	if e == nil {
+		return nil
+	}
+	return ev.eval(e, s)
+}
+
+// evalList evaluates a list of expressions and expands unfold expressions (the splat operator)
+func (ev *Evaluator) evalList(exprs []parser.Expression, s *scope) []interface{} {
+	result := make([]interface{}, 0, len(exprs))
+	for _, e := range exprs {
+		if u, ok := e.(*parser.UnfoldExpression); ok {
+			v := ev.eval(u.Expr(), s)
+			if a, ok := v.([]interface{}); ok {
+				result = append(result, a...)
+			} else {
+				result = append(result, v)
+			}
+			continue
+		}
+		result = append(result, ev.eval(e, s))
+	}
+	return result
+}
+
+func (ev *Evaluator) evalAssignment(e *parser.AssignmentExpression, s *scope) interface{} {
+	if e.Operator() != `=` {
+		panic(evalError(EvalUnsupportedExpression, issue.H{`expression`: e}, e))
+	}
+	value := ev.eval(e.Rhs(), s)
+	switch lhs := e.Lhs().(type) {
+	case *parser.VariableExpression:
+		ev.assign(lhs, value, s)
+	case *parser.LiteralList:
+		names := lhs.Elements()
+		switch value := value.(type) {
+		case []interface{}:
+			if len(names) != len(value) {
+				panic(evalError(literal.LiteralAssignmentCountMismatch, issue.H{`expected`: len(names), `actual`: len(value)}, e))
+			}
+			for idx, name := range names {
+				ev.assign(name, value[idx], s)
+			}
+		case *literal.Hash:
+			for _, name := range names {
+				if ve, ok := name.(*parser.VariableExpression); ok {
+					if n, ok := ve.Name(); ok {
+						v, _ := value.Get(n)
+						ev.assign(ve, v, s)
+						continue
+					}
+				}
+				panic(evalError(EvalUnsupportedExpression, issue.H{`expression`: name}, name))
+			}
+		default:
+			panic(evalError(EvalUnsupportedExpression, issue.H{`expression`: e}, e))
+		}
+	default:
+		panic(evalError(EvalUnsupportedExpression, issue.H{`expression`: lhs}, lhs))
+	}
+	return value
+}
+
+func (ev *Evaluator) assign(lhs parser.Expression, value interface{}, s *scope) {
+	if ve, ok := lhs.(*parser.VariableExpression); ok {
+		if name, ok := ve.Name(); ok {
+			if _, found := s.vars[name]; found {
+				panic(evalError(EvalVariableAlreadyAssigned, issue.H{`name`: name}, lhs))
+			}
+			s.vars[name] = value
+			return
+		}
+	}
+	panic(evalError(EvalUnsupportedExpression, issue.H{`expression`: lhs}, lhs))
+}
+
+func (ev *Evaluator) evalVariable(e *parser.VariableExpression, s *scope) interface{} {
+	if name, ok := e.Name(); ok {
+		if strings.HasPrefix(name, `::`) {
+			name = name[2:]
+			s = ev.global
+		}
+		if value, found := s.get(name); found {
+			return value
+		}
+	}
+	panic(evalError(EvalUnknownVariable, issue.H{`name`: e.NameOrIndex()}, e))
+}
+
+func (ev *Evaluator) evalMatch(e *parser.MatchExpression, s *scope) interface{} {
+	lhs := ev.eval(e.Lhs(), s)
+	rhs := ev.eval(e.Rhs(), s)
+	var match bool
+	switch rhs := rhs.(type) {
+	case literal.Type:
+		match = rhs.IsInstance(lhs)
+	case *regexp.Regexp, string:
+		ls, ok := lhs.(string)
+		if !ok {
+			panic(evalError(EvalMatchNotApplicable, issue.H{`operator`: e.Operator(), `left`: literal.TypeName(lhs), `right`: literal.TypeName(rhs)}, e))
+		}
+		rx, ok := rhs.(*regexp.Regexp)
+		if !ok {
+			rx = ev.compileRegexp(rhs.(string), e.Rhs())
+		}
+		match = rx.MatchString(ls)
+	default:
+		panic(evalError(EvalMatchNotApplicable, issue.H{`operator`: e.Operator(), `left`: literal.TypeName(lhs), `right`: literal.TypeName(rhs)}, e))
+	}
+	if e.Operator() == `!~` {
+		return !match
+	}
+	return match
+}
+
+func (ev *Evaluator) evalCase(e *parser.CaseExpression, s *scope) interface{} {
+	test := ev.eval(e.Test(), s)
+	var dflt *parser.CaseOption
+	for _, o := range e.Options() {
+		co := o.(*parser.CaseOption)
+		for _, ve := range co.Values() {
+			if _, ok := ve.(*parser.LiteralDefault); ok {
+				dflt = co
+				continue
+			}
+			if ev.caseMatch(ev.eval(ve, s), test) {
+				return ev.eval(co.Then(), s)
+			}
+		}
+	}
+	if dflt != nil {
+		return ev.eval(dflt.Then(), s)
+	}
+	return nil
+}
+
+func (ev *Evaluator) evalSelector(e *parser.SelectorExpression, s *scope) interface{} {
+	test := ev.eval(e.Lhs(), s)
+	var dflt *parser.SelectorEntry
+	for _, se := range e.Selectors() {
+		entry := se.(*parser.SelectorEntry)
+		if _, ok := entry.Matching().(*parser.LiteralDefault); ok {
+			dflt = entry
+			continue
+		}
+		if ev.caseMatch(ev.eval(entry.Matching(), s), test) {
+			return ev.eval(entry.Value(), s)
+		}
+	}
+	if dflt != nil {
+		return ev.eval(dflt.Value(), s)
+	}
+	panic(evalError(EvalNoSelectorMatch, issue.H{`value`: literal.ToString(test)}, e))
+}
+
+// caseMatch matches a case option or selector entry against a value. Regular expressions
+// match strings, types match their instances, arrays match element wise, and all other
+// values match when they are equal.
+func (ev *Evaluator) caseMatch(option, value interface{}) bool {
+	switch option := option.(type) {
+	case parser.Default:
+		return true
+	case *regexp.Regexp:
+		if s, ok := value.(string); ok {
+			return option.MatchString(s)
+		}
+		return false
+	case literal.Type:
+		return option.IsInstance(value)
+	case []interface{}:
+		if a, ok := value.([]interface{}); ok && len(a) == len(option) {
+			for i, o := range option {
+				if !ev.caseMatch(o, a[i]) {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	}
+	return literal.Equals(option, value)
+}
+
+func (ev *Evaluator) resolveType(e parser.Expression) literal.Type {
+	t, err := literal.ResolveType(e, ev.aliases)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func (ev *Evaluator) compileRegexp(pattern string, e parser.Expression) *regexp.Regexp {
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(evalError(EvalMatchNotApplicable, issue.H{`operator`: `=~`, `left`: `String`, `right`: `invalid Regexp`}, e))
+	}
+	return rx
+}
+
+func (ev *Evaluator) closure(e parser.Expression, s *scope) Lambda {
+	if le, ok := e.(*parser.LambdaExpression); ok {
+		return &closure{ev, le, s}
+	}
+	return nil
+}
+
+func (ev *Evaluator) callFunction(name string, args []interface{}, block Lambda, call parser.Expression) interface{} {
+	name = normalizeName(name)
+	if fd, ok := ev.definitions[name]; ok {
+		return ev.callDefinition(fd, args, call)
+	}
+	if f, ok := ev.functions[name]; ok {
+		defer locateSignal(call)
+		value, err := f(args, block)
+		if err != nil {
+			if ri, ok := err.(issue.Reported); ok {
+				if call != nil {
+					ri = ri.WithLocation(call)
+				}
+				panic(ri)
+			}
+			panic(evalError(EvalFunctionError, issue.H{`function`: name, `message`: err.Error()}, call))
+		}
+		return value
+	}
+	panic(evalError(EvalUnknownFunction, issue.H{`name`: name}, call))
+}
+
+func (ev *Evaluator) callDefinition(fd *parser.FunctionDefinition, args []interface{}, call parser.Expression) (value interface{}) {
+	label := `function '` + fd.Name() + `'`
+	s := newScope(ev.global)
+	ev.bindParameters(label, fd.Parameters(), args, s, call)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if rs, ok := r.(*returnSignal); ok {
+					value = rs.value
+				} else {
+					panic(r)
+				}
+			}
+		}()
+		value = ev.evalTop(fd.Body(), s)
+	}()
+	if fd.ReturnType() != nil {
+		t := ev.resolveType(fd.ReturnType())
+		if !t.IsInstance(value) {
+			panic(evalError(EvalReturnTypeMismatch, issue.H{`function`: label, `expected`: t.String(), `actual`: literal.TypeString(value)}, fd))
+		}
+	}
+	return
+}
+
+// bindParameters binds the given arguments to the given parameters in the given scope. Missing arguments
+// are assigned the parameters default value if it has one.
+func (ev *Evaluator) bindParameters(label string, params []parser.Expression, args []interface{}, s *scope, call parser.Expression) {
+	min := 0
+	max := len(params)
+	for i, pe := range params {
+		p := pe.(*parser.Parameter)
+		if p.CapturesRest() {
+			max = -1
+		} else if p.Value() == nil {
+			min = i + 1
+		}
+	}
+	if len(args) < min || max >= 0 && len(args) > max {
+		panic(evalError(EvalWrongArgumentCount, issue.H{`function`: label, `expected`: expectedCount(min, max), `actual`: len(args)}, locationOf(call, params)))
+	}
+
+	for i, pe := range params {
+		p := pe.(*parser.Parameter)
+		var value interface{}
+		switch {
+		case p.CapturesRest():
+			if i < len(args) {
+				value = append([]interface{}{}, args[i:]...)
+			} else {
+				value = []interface{}{}
+			}
+		case i < len(args):
+			value = args[i]
+		default:
+			value = ev.eval(p.Value(), s)
+		}
+		if p.Type() != nil {
+			t := ev.resolveType(p.Type())
+			ok := true
+			if p.CapturesRest() {
+				for _, e := range value.([]interface{}) {
+					if !t.IsInstance(e) {
+						value = e
+						ok = false
+						break
+					}
+				}
+			} else {
+				ok = t.IsInstance(value)
+			}
+			if !ok {
+				panic(evalError(EvalTypeMismatch, issue.H{`function`: label, `name`: p.Name(), `expected`: t.String(), `actual`: literal.TypeString(value)}, locationOf(call, params)))
+			}
+		}
+		s.vars[p.Name()] = value
+	}
+}
+
+func (c *closure) ParameterCount() int {
+	return len(c.lambda.Parameters())
+}
+
+func (c *closure) Call(args ...interface{}) (value interface{}) {
+	s := newScope(c.scope)
+	c.ev.bindParameters(`lambda`, c.lambda.Parameters(), args, s, c.lambda)
+	defer func() {
+		if r := recover(); r != nil {
+			if ns, ok := r.(*nextSignal); ok {
+				value = ns.value
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	value = c.ev.eval(c.lambda.Body(), s)
+	if c.lambda.ReturnType() != nil {
+		t := c.ev.resolveType(c.lambda.ReturnType())
+		if !t.IsInstance(value) {
+			panic(evalError(EvalReturnTypeMismatch, issue.H{`function`: `lambda`, `expected`: t.String(), `actual`: literal.TypeString(value)}, c.lambda))
+		}
+	}
+	return
+}
+
+// locateSignal assigns the location of the call to a control signal that was raised by
+// a call to break(), next(), or return()
+func locateSignal(call parser.Expression) {
+	if r := recover(); r != nil {
+		switch r := r.(type) {
+		case *breakSignal:
+			if r.call == nil {
+				r.call = call
+			}
+		case *nextSignal:
+			if r.call == nil {
+				r.call = call
+			}
+		case *returnSignal:
+			if r.call == nil {
+				r.call = call
+			}
+		}
+		panic(r)
+	}
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent, vars: make(map[string]interface{})}
+}
+
+func (s *scope) get(name string) (interface{}, bool) {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(name, `::`))
+}
+
+func expectedCount(min, max int) string {
+	switch {
+	case max < 0:
+		return `at least ` + itoa(min)
+	case min == max:
+		return itoa(min)
+	default:
+		return `between ` + itoa(min) + ` and ` + itoa(max)
+	}
+}
+
+func itoa(i int) string {
+	return strconv.Itoa(i)
+}
+
+// locationOf returns the location of the call, or when the call is unknown, the location of
+// the first parameter.
+func locationOf(call parser.Expression, params []parser.Expression) issue.Location {
+	if call != nil {
+		return call
+	}
+	if len(params) > 0 {
+		return params[0]
+	}
+	return nil
+}
+
+func check(value interface{}, err error) interface{} {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+func evalError(code issue.Code, args issue.H, location issue.Location) issue.Reported {
+	return issue.NewReported(code, issue.SeverityError, args, location)
+}
+
+// ToValue converts a Go value into the representation used by the evaluator. Booleans, strings, integers,
+// floats, slices, arrays, and maps of any Go type are converted using reflection. Integers are converted to
+// int64, floats to float64, slices and arrays to []interface{}, and maps to *literal.Hash with the entries
+// sorted by key. A pointer is converted to the value that it points to, or to undef when it is nil. Values
+// that already are in the representation of the evaluator are returned unchanged. An error is returned for
+// all other values and for unsigned integers that don't fit in an int64.
+func ToValue(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, bool, int64, float64, string, parser.Default, *literal.Hash, *regexp.Regexp, literal.Type:
+		return v, nil
+	}
+	return toValue(reflect.ValueOf(v))
+}
+
+func toValue(rv reflect.Value) (interface{}, error) {
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u), nil
+		}
+		return nil, issue.NewReported(EvalValueOutOfRange, issue.SeverityError, issue.H{`type`: rv.Type().String(), `value`: rv.Uint()}, nil)
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.Slice, reflect.Array:
+		a := make([]interface{}, rv.Len())
+		for i := range a {
+			e, err := toValue(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			a[i] = e
+		}
+		return a, nil
+	case reflect.Map:
+		keys := make([]interface{}, 0, rv.Len())
+		values := make(map[interface{}]interface{}, rv.Len())
+		for _, rk := range rv.MapKeys() {
+			k, err := toValue(rk)
+			if err != nil {
+				return nil, err
+			}
+			e, err := toValue(rv.MapIndex(rk))
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+			values[k] = e
+		}
+		sort.Slice(keys, func(i, j int) bool { return literal.ToString(keys[i]) < literal.ToString(keys[j]) })
+		h := literal.NewHash(len(keys))
+		for _, k := range keys {
+			h.Put(k, values[k])
+		}
+		return h, nil
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if rv.CanInterface() {
+			return ToValue(rv.Elem().Interface())
+		}
+		return toValue(rv.Elem())
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	return nil, issue.NewReported(EvalUnsupportedValue, issue.SeverityError, issue.H{`type`: rv.Type().String()}, nil)
+}
@@ -0,0 +1,180 @@
+package eval
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/validator"
+)
+
+func TestEvalVariablesAndOperators(t *testing.T) {
+	expectResult(t, issue.Unindent(`
+    $a = 3
+    [$b, $c] = [$a * 2, "x${a}"]
+    [$b - 1, $c, $a in [1, 2, 3], $c =~ /^x\d$/, $b =~ Integer[6, 6]]`),
+		[]interface{}{int64(5), `x3`, true, true, true})
+}
+
+func TestEvalConditionals(t *testing.T) {
+	expectResult(t, issue.Unindent(`
+    $x = 'Debian'
+    $a = if $x == 'debian' { 'deb' } else { 'other' }
+    $b = unless $x =~ String { 1 } else { 2 }
+    $c = case $x {
+      'RedHat', 'CentOS': { 'rpm' }
+      default: { 'unknown' }
+      /^Deb/: { 'apt' }
+    }
+    $d = $x ? { Integer => 'int', default => 'str' }
+    [$a, $b, $c, $d]`),
+		[]interface{}{`deb`, int64(2), `apt`, `str`})
+}
+
+func TestEvalIteration(t *testing.T) {
+	expectResult(t, issue.Unindent(`
+    $a = [1, 2, 3, 4]
+    $sum = $a.reduce |$memo, $x| { $memo + $x }
+    $doubled = $a.map |$x| { $x * 2 }
+    $even = $a.filter |$x| { $x % 2 == 0 }
+    $h = {b => 2, a => 1}.map |$k, $v| { "${k}=${v}" }
+    $first = $a.map |$i, $x| { if $i > 1 { break() } $x }
+    $skip = $a.map |$x| { if $x == 2 { next(0) } $x }
+    [$sum, $doubled, $even, $h, $first, $skip, map(3) |$x| { $x }]`),
+		[]interface{}{
+			int64(10),
+			[]interface{}{int64(2), int64(4), int64(6), int64(8)},
+			[]interface{}{int64(2), int64(4)},
+			[]interface{}{`b=2`, `a=1`},
+			[]interface{}{int64(1), int64(2)},
+			[]interface{}{int64(1), int64(0), int64(3), int64(4)},
+			[]interface{}{int64(0), int64(1), int64(2)}})
+}
+
+func TestEvalIterationEdgeCases(t *testing.T) {
+	expectResult(t, issue.Unindent(`
+    $negative = (-1).map |$x| { $x }
+    $zero = map(0) |$x| { $x }
+    $partial = [1, 2, 3, 4].reduce |$memo, $x| { if $x > 3 { break() } $memo + $x }
+    $none = [].reduce |$memo, $x| { $memo + $x }
+    $filtered = {c => 3, a => 1, b => 2}.filter |$k, $v| { $v > 1 }
+    [$negative, $zero, $partial, $none, $filtered]`),
+		[]interface{}{
+			[]interface{}{},
+			[]interface{}{},
+			int64(6),
+			nil,
+			literal.HashOf(`c`, int64(3), `b`, int64(2))})
+}
+
+func TestEvalFunctionDefinitions(t *testing.T) {
+	ev := NewEvaluator()
+	err := ev.Load(parse(t, issue.Unindent(`
+    type Mymod::Port = Integer[1, 65535]
+
+    function mymod::url(String $host, Mymod::Port $port = 80, *$path) >> String {
+      $base = "http://${host}:${port}"
+      if $path =~ Array[String, 1] {
+        return("${base}/${path.reduce |$m, $p| { "${m}/${p}" }}")
+      }
+      $base
+    }
+
+    function mymod::fact(Integer $n) >> Integer {
+      if $n <= 1 { 1 } else { $n * mymod::fact($n - 1) }
+    }`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectCall(t, ev, `http://example.com:80`, `mymod::url`, `example.com`)
+	expectCall(t, ev, `http://example.com:8080/a/b`, `mymod::url`, `example.com`, int64(8080), `a`, `b`)
+	expectCall(t, ev, int64(120), `mymod::fact`, int64(5))
+	expectCallError(t, ev, EvalTypeMismatch, `mymod::url`, `example.com`, int64(0))
+	expectCallError(t, ev, EvalWrongArgumentCount, `mymod::fact`)
+}
+
+func TestEvalGoFunction(t *testing.T) {
+	ev := NewEvaluator()
+	ev.AddFunction(`upcase`, func(args []interface{}, block Lambda) (interface{}, error) {
+		return args[0].(string) + `!`, nil
+	})
+	ev.SetVariable(`greeting`, `hello`)
+	v, err := ev.Evaluate(parse(t, `upcase($::greeting)`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != `hello!` {
+		t.Errorf(`expected 'hello!', got %v`, v)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	expectError(t, `file { '/tmp/x': ensure => file }`, validator.ValidateCatalogOperationNotSupported)
+	expectError(t, `$x = $y + 1`, EvalUnknownVariable)
+	expectError(t, `$x = 1 $x = 2`, EvalVariableAlreadyAssigned)
+	expectError(t, `$x = foo(1)`, EvalUnknownFunction)
+	expectError(t, `$x = 3 ? { 1 => a }`, EvalNoSelectorMatch)
+	expectError(t, `fail('it broke')`, EvalFail)
+	expectError(t, `next()`, EvalIllegalNext)
+	expectError(t, `'a'.each |$x| { }`, EvalNotIterable)
+	expectError(t, `[1].each`, EvalMissingBlock)
+	expectError(t, `$x = 1 + 'a'`, `LITERAL_OPERATOR_NOT_APPLICABLE`)
+}
+
+func expectResult(t *testing.T, source string, expected interface{}) {
+	t.Helper()
+	v, err := NewEvaluator().Evaluate(parse(t, source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, v) {
+		t.Errorf(`expected %#v, got %#v`, expected, v)
+	}
+}
+
+func expectError(t *testing.T, source string, code issue.Code) {
+	t.Helper()
+	_, err := NewEvaluator().Evaluate(parse(t, source))
+	if err == nil {
+		t.Errorf(`expected %s, got no error`, code)
+		return
+	}
+	if ri, ok := err.(issue.Reported); !ok || ri.Code() != code {
+		t.Errorf(`expected %s, got %s`, code, err.Error())
+	}
+}
+
+func expectCall(t *testing.T, ev *Evaluator, expected interface{}, name string, args ...interface{}) {
+	t.Helper()
+	v, err := ev.Call(name, args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, v) {
+		t.Errorf(`expected %#v, got %#v`, expected, v)
+	}
+}
+
+func expectCallError(t *testing.T, ev *Evaluator, code issue.Code, name string, args ...interface{}) {
+	t.Helper()
+	_, err := ev.Call(name, args...)
+	if err == nil {
+		t.Errorf(`expected %s, got no error`, code)
+		return
+	}
+	if ri, ok := err.(issue.Reported); !ok || ri.Code() != code {
+		t.Errorf(`expected %s, got %s`, code, err.Error())
+	}
+}
+
+func parse(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(``, source, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return expr
+}
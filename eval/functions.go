@@ -0,0 +1,206 @@
+package eval
+
+import (
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
+)
+
+// The built-in functions. Iteration over a hash uses the insertion order of its keys.
+var builtins = map[string]Function{
+	`break`:  breakFunc,
+	`each`:   each,
+	`fail`:   fail,
+	`filter`: filter,
+	`map`:    mapFunc,
+	`next`:   next,
+	`reduce`: reduce,
+	`return`: returnFunc,
+}
+
+func breakFunc(args []interface{}, block Lambda) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, wrongArgumentCount(`break`, `0`, len(args))
+	}
+	panic(&breakSignal{})
+}
+
+func next(args []interface{}, block Lambda) (interface{}, error) {
+	value, err := optionalValue(`next`, args)
+	if err != nil {
+		return nil, err
+	}
+	panic(&nextSignal{value: value})
+}
+
+func returnFunc(args []interface{}, block Lambda) (interface{}, error) {
+	value, err := optionalValue(`return`, args)
+	if err != nil {
+		return nil, err
+	}
+	panic(&returnSignal{value: value})
+}
+
+func fail(args []interface{}, block Lambda) (interface{}, error) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		strs[i] = literal.ToString(arg)
+	}
+	return nil, issue.NewReported(EvalFail, issue.SeverityError, issue.H{`message`: strings.Join(strs, ` `)}, nil)
+}
+
+func each(args []interface{}, block Lambda) (interface{}, error) {
+	if err := iterationArgs(`each`, args, 1, block); err != nil {
+		return nil, err
+	}
+	iterate(args[0], func(key, value interface{}) { callBlock(block, args[0], key, value) })
+	return args[0], nil
+}
+
+func mapFunc(args []interface{}, block Lambda) (interface{}, error) {
+	if err := iterationArgs(`map`, args, 1, block); err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, 0)
+	iterate(args[0], func(key, value interface{}) { result = append(result, callBlock(block, args[0], key, value)) })
+	return result, nil
+}
+
+func filter(args []interface{}, block Lambda) (interface{}, error) {
+	if err := iterationArgs(`filter`, args, 1, block); err != nil {
+		return nil, err
+	}
+	if _, ok := args[0].(*literal.Hash); ok {
+		result := literal.NewHash(0)
+		iterate(args[0], func(key, value interface{}) {
+			if literal.IsTruthy(callBlock(block, args[0], key, value)) {
+				result.Put(key, value)
+			}
+		})
+		return result, nil
+	}
+	result := make([]interface{}, 0)
+	iterate(args[0], func(key, value interface{}) {
+		if literal.IsTruthy(callBlock(block, args[0], key, value)) {
+			result = append(result, value)
+		}
+	})
+	return result, nil
+}
+
+func reduce(args []interface{}, block Lambda) (interface{}, error) {
+	if len(args) != 1 && len(args) != 2 {
+		return nil, wrongArgumentCount(`reduce`, `between 1 and 2`, len(args))
+	}
+	if err := iterationArgs(`reduce`, args[:1], 1, block); err != nil {
+		return nil, err
+	}
+
+	// The elements of a hash are [key, value] tuples
+	first := len(args) == 1
+	var memo interface{}
+	if !first {
+		memo = args[1]
+	}
+	_, isHash := args[0].(*literal.Hash)
+	iterate(args[0], func(key, value interface{}) {
+		if isHash {
+			value = []interface{}{key, value}
+		}
+		if first {
+			memo = value
+			first = false
+			return
+		}
+		memo = block.Call(memo, value)
+	})
+	return memo, nil
+}
+
+// iterate calls the given function once for each element of the given collection, with the index and
+// the element of an array or an integer, or with the key and the value of a hash. A hash is iterated in
+// insertion order. A break() from within the function ends the iteration.
+func iterate(collection interface{}, visit func(key, value interface{})) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*breakSignal); !ok {
+				panic(r)
+			}
+		}
+	}()
+	if h, ok := collection.(*literal.Hash); ok {
+		for _, k := range h.Keys() {
+			v, _ := h.Get(k)
+			visit(k, v)
+		}
+		return
+	}
+	for i, e := range elementsOf(collection) {
+		visit(int64(i), e)
+	}
+}
+
+// callBlock calls the block with the element of an array or with a [key, value] tuple of a hash, or with
+// the index and the element or the key and the value when the block declares two parameters
+func callBlock(block Lambda, collection, key, value interface{}) interface{} {
+	if block.ParameterCount() == 2 {
+		return block.Call(key, value)
+	}
+	if _, ok := collection.(*literal.Hash); ok {
+		return block.Call([]interface{}{key, value})
+	}
+	return block.Call(value)
+}
+
+// elementsOf returns the elements of an array, or the integers from zero up to, but not
+// including, the given integer. A count that is zero or negative yields no elements.
+func elementsOf(collection interface{}) []interface{} {
+	switch c := collection.(type) {
+	case []interface{}:
+		return c
+	case int64:
+		if c <= 0 {
+			return []interface{}{}
+		}
+		elements := make([]interface{}, 0, c)
+		for i := int64(0); i < c; i++ {
+			elements = append(elements, i)
+		}
+		return elements
+	}
+	return nil
+}
+
+func iterationArgs(name string, args []interface{}, count int, block Lambda) error {
+	if len(args) != count {
+		return wrongArgumentCount(name, itoa(count), len(args))
+	}
+	switch args[0].(type) {
+	case []interface{}, *literal.Hash, int64:
+	default:
+		return issue.NewReported(EvalNotIterable, issue.SeverityError, issue.H{`function`: name, `type`: literal.TypeName(args[0])}, nil)
+	}
+	if block == nil {
+		return issue.NewReported(EvalMissingBlock, issue.SeverityError, issue.H{`function`: name}, nil)
+	}
+	if pc := block.ParameterCount(); pc < 1 || pc > 2 {
+		return issue.NewReported(EvalWrongArgumentCount, issue.SeverityError, issue.H{`function`: `'` + name + `' block`, `expected`: `between 1 and 2`, `actual`: pc}, nil)
+	}
+	return nil
+}
+
+func optionalValue(name string, args []interface{}) (interface{}, error) {
+	switch len(args) {
+	case 0:
+		return nil, nil
+	case 1:
+		return args[0], nil
+	default:
+		return nil, wrongArgumentCount(name, `between 0 and 1`, len(args))
+	}
+}
+
+func wrongArgumentCount(name, expected string, actual int) error {
+	return issue.NewReported(EvalWrongArgumentCount, issue.SeverityError, issue.H{`function`: `function '` + name + `'`, `expected`: expected, `actual`: actual}, nil)
+}
@@ -0,0 +1,63 @@
+package eval
+
+import (
+	"github.com/lyraproj/issue/issue"
+)
+
+const (
+	EvalFail                    = `EVAL_FAIL`
+	EvalFunctionError           = `EVAL_FUNCTION_ERROR`
+	EvalIllegalBreak            = `EVAL_ILLEGAL_BREAK`
+	EvalIllegalNext             = `EVAL_ILLEGAL_NEXT`
+	EvalMatchNotApplicable      = `EVAL_MATCH_NOT_APPLICABLE`
+	EvalMissingBlock            = `EVAL_MISSING_BLOCK`
+	EvalNoSelectorMatch         = `EVAL_NO_SELECTOR_MATCH`
+	EvalNotIterable             = `EVAL_NOT_ITERABLE`
+	EvalReturnTypeMismatch      = `EVAL_RETURN_TYPE_MISMATCH`
+	EvalTypeMismatch            = `EVAL_TYPE_MISMATCH`
+	EvalUnknownFunction         = `EVAL_UNKNOWN_FUNCTION`
+	EvalUnknownVariable         = `EVAL_UNKNOWN_VARIABLE`
+	EvalUnsupportedExpression   = `EVAL_UNSUPPORTED_EXPRESSION`
+	EvalUnsupportedValue        = `EVAL_UNSUPPORTED_VALUE`
+	EvalValueOutOfRange         = `EVAL_VALUE_OUT_OF_RANGE`
+	EvalVariableAlreadyAssigned = `EVAL_VARIABLE_ALREADY_ASSIGNED`
+	EvalWrongArgumentCount      = `EVAL_WRONG_ARGUMENT_COUNT`
+)
+
+func init() {
+	issue.Hard(EvalFail, `%{message}`)
+
+	issue.Hard(EvalFunctionError, `Error while calling function '%{function}': %{message}`)
+
+	issue.Hard(EvalIllegalBreak, `break() from context where this is illegal`)
+
+	issue.Hard(EvalIllegalNext, `next() from context where this is illegal`)
+
+	issue.Hard2(EvalMatchNotApplicable, `Operator '%{operator}' is not applicable to %{left} and %{right}`,
+		issue.HF{`left`: issue.AnOrA, `right`: issue.AnOrA})
+
+	issue.Hard(EvalMissingBlock, `Function '%{function}' expects a block`)
+
+	issue.Hard(EvalNoSelectorMatch, `No matching entry for selector parameter with value '%{value}'`)
+
+	issue.Hard2(EvalNotIterable, `%{function}() cannot iterate over %{type}`, issue.HF{`type`: issue.AnOrA})
+
+	issue.Hard(EvalReturnTypeMismatch, `%{function} expects a return value of type %{expected}, got %{actual}`)
+
+	issue.Hard(EvalTypeMismatch, `%{function} parameter '%{name}' expects a value of type %{expected}, got %{actual}`)
+
+	issue.Hard(EvalUnknownFunction, `Unknown function: '%{name}'`)
+
+	issue.Hard(EvalUnknownVariable, `Unknown variable: '$%{name}'`)
+
+	issue.Hard2(EvalUnsupportedExpression, `%{expression} cannot be evaluated without compiling a catalog`,
+		issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard(EvalUnsupportedValue, `A Go value of type %{type} cannot be converted to a Puppet value`)
+
+	issue.Hard(EvalValueOutOfRange, `The Go value %{value} of type %{type} is out of the range of a 64-bit Integer`)
+
+	issue.Hard(EvalVariableAlreadyAssigned, `Cannot reassign variable '$%{name}'`)
+
+	issue.Hard(EvalWrongArgumentCount, `%{function} expects %{expected} arguments, got %{actual}`)
+}
@@ -1,3 +1,6 @@
 module github.com/lyraproj/puppet-parser
 
-require github.com/lyraproj/issue v0.0.0-20190606092846-e082d6813d15
+require (
+	github.com/lyraproj/issue v0.0.0-20190606092846-e082d6813d15
+	gopkg.in/yaml.v2 v2.4.0
+)
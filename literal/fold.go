@@ -0,0 +1,261 @@
+package literal
+
+import (
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// A Folder evaluates expressions that can be reduced to a constant value at parse time. In addition to
+// the literals accepted by ToLiteral, a Folder handles string interpolation, arithmetic, comparisons,
+// the 'in', 'and', 'or', and 'not' operators, access using [], and variables that have been assigned
+// constant values earlier in the same scope.
+//
+// A Folder retains the variables that it has seen assigned so that subsequent calls to Fold can make
+// use of them.
+type Folder struct {
+	variables map[string]interface{}
+}
+
+// Fold folds the given expression using a new Folder.
+func Fold(e parser.Expression) (interface{}, error) {
+	return NewFolder().Fold(e)
+}
+
+// NewFolder creates a new Folder with an empty scope.
+func NewFolder() *Folder {
+	return &Folder{variables: make(map[string]interface{})}
+}
+
+// Fold reduces the given expression to a constant value. An error is returned when that is not
+// possible. The error is an issue.Reported that appoints the sub-expression that prevented the
+// folding.
+func (f *Folder) Fold(e parser.Expression) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ri, ok := r.(issue.Reported); ok {
+				err = ri
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	value = f.fold(e)
+	return
+}
+
+// SetVariable assigns a constant value to a variable in the scope of the receiver.
+func (f *Folder) SetVariable(name string, value interface{}) {
+	f.variables[name] = value
+}
+
+// Variable returns the value of the given variable and true if the variable has been
+// assigned a constant value. Otherwise, nil, false is returned.
+func (f *Folder) Variable(name string) (interface{}, bool) {
+	v, ok := f.variables[name]
+	return v, ok
+}
+
+// Variables returns a copy of the variables that have been assigned constant values.
+func (f *Folder) Variables() map[string]interface{} {
+	vars := make(map[string]interface{}, len(f.variables))
+	for k, v := range f.variables {
+		vars[k] = v
+	}
+	return vars
+}
+
+func (f *Folder) fold(e parser.Expression) interface{} {
+	switch e := e.(type) {
+	case *parser.Program:
+		return f.fold(e.Body())
+	case *parser.BlockExpression:
+		return f.foldBlock(e.Statements())
+	case *parser.Nop:
+		return nil
+	case *parser.ParenthesizedExpression:
+		return f.fold(e.Expr())
+	case *parser.AssignmentExpression:
+		return f.foldAssignment(e)
+	case *parser.VariableExpression:
+		return f.foldVariable(e)
+	case *parser.ConcatenatedString:
+		return f.foldConcatenatedString(e)
+	case *parser.TextExpression:
+		return ToString(f.fold(e.Expr()))
+	case *parser.HeredocExpression:
+		return f.fold(e.Text())
+	case *parser.LiteralList:
+		elements := e.Elements()
+		result := make([]interface{}, len(elements))
+		for idx, elem := range elements {
+			result[idx] = f.fold(elem)
+		}
+		return result
+	case *parser.LiteralHash:
+		entries := e.Entries()
+		result := NewHash(len(entries))
+		for _, entry := range entries {
+			kh := entry.(*parser.KeyedEntry)
+			key := f.fold(kh.Key())
+			if !isHashable(key) {
+				panic(issue.NewReported(LiteralIllegalHashKey, issue.SeverityError, issue.H{`type`: TypeName(key)}, kh.Key()))
+			}
+			result.Put(key, f.fold(kh.Value()))
+		}
+		return result
+	case *parser.ArithmeticExpression:
+		return check(Arithmetic(e.Operator(), f.fold(e.Lhs()), f.fold(e.Rhs()), e))
+	case *parser.ComparisonExpression:
+		return check(Compare(e.Operator(), f.fold(e.Lhs()), f.fold(e.Rhs()), e))
+	case *parser.InExpression:
+		return In(f.fold(e.Lhs()), f.fold(e.Rhs()))
+	case *parser.AndExpression:
+		return IsTruthy(f.fold(e.Lhs())) && IsTruthy(f.fold(e.Rhs()))
+	case *parser.OrExpression:
+		return IsTruthy(f.fold(e.Lhs())) || IsTruthy(f.fold(e.Rhs()))
+	case *parser.NotExpression:
+		return !IsTruthy(f.fold(e.Expr()))
+	case *parser.UnaryMinusExpression:
+		return check(Negate(f.fold(e.Expr()), e))
+	case *parser.AccessExpression:
+		operand := f.fold(e.Operand())
+		keyExprs := e.Keys()
+		keys := make([]interface{}, len(keyExprs))
+		for idx, key := range keyExprs {
+			keys[idx] = f.fold(key)
+		}
+		return check(Access(operand, keys, e))
+	case *parser.QualifiedReference, *parser.RegexpExpression:
+		// Types and regular expressions have no constant representation
+		panic(notConstant(e))
+	case parser.LiteralValue:
+		return e.Value()
+	default:
+		panic(notConstant(e))
+	}
+}
+
+// foldBlock folds all statements and returns the value of the last one. Statements other than the last one
+// that cannot be folded are ignored, but variables that they assign are considered unresolved.
+func (f *Folder) foldBlock(statements []parser.Expression) (value interface{}) {
+	last := len(statements) - 1
+	for idx, stmt := range statements {
+		if idx == last {
+			value = f.fold(stmt)
+		} else if _, err := f.Fold(stmt); err != nil {
+			f.unresolveAssignments(stmt)
+		}
+	}
+	return
+}
+
+func (f *Folder) foldAssignment(e *parser.AssignmentExpression) interface{} {
+	if e.Operator() != `=` {
+		panic(notConstant(e))
+	}
+	value := f.fold(e.Rhs())
+	switch lhs := e.Lhs().(type) {
+	case *parser.VariableExpression:
+		f.assign(lhs, value)
+	case *parser.LiteralList:
+		names := lhs.Elements()
+		switch value := value.(type) {
+		case []interface{}:
+			if len(names) != len(value) {
+				panic(issue.NewReported(LiteralAssignmentCountMismatch, issue.SeverityError, issue.H{`expected`: len(names), `actual`: len(value)}, e))
+			}
+			for idx, name := range names {
+				f.assign(name, value[idx])
+			}
+		case *Hash:
+			for _, name := range names {
+				if ve, ok := name.(*parser.VariableExpression); ok {
+					if n, ok := ve.Name(); ok {
+						v, _ := value.Get(n)
+						f.assign(name, v)
+						continue
+					}
+				}
+				panic(notConstant(name))
+			}
+		default:
+			panic(notConstant(e.Rhs()))
+		}
+	default:
+		panic(notConstant(lhs))
+	}
+	return value
+}
+
+func (f *Folder) assign(lhs parser.Expression, value interface{}) {
+	if ve, ok := lhs.(*parser.VariableExpression); ok {
+		if name, ok := ve.Name(); ok {
+			f.SetVariable(name, value)
+			return
+		}
+	}
+	panic(notConstant(lhs))
+}
+
+// unresolveAssignments marks all variables that are assigned by the given expression as unresolved.
+// Assignments in lambdas and definitions are ignored since they belong to another scope.
+func (f *Folder) unresolveAssignments(e parser.Expression) {
+	var visit parser.PathVisitor
+	visit = func(path []parser.Expression, e parser.Expression) {
+		switch e := e.(type) {
+		case *parser.LambdaExpression, parser.Definition:
+			return
+		case *parser.AssignmentExpression:
+			f.unresolveTargets(e.Lhs())
+		}
+		e.Contents(path, visit)
+	}
+	visit(nil, e)
+}
+
+func (f *Folder) unresolveTargets(lhs parser.Expression) {
+	switch lhs := lhs.(type) {
+	case *parser.VariableExpression:
+		if name, ok := lhs.Name(); ok {
+			delete(f.variables, name)
+		}
+	case *parser.LiteralList:
+		for _, elem := range lhs.Elements() {
+			f.unresolveTargets(elem)
+		}
+	}
+}
+
+func (f *Folder) foldVariable(e *parser.VariableExpression) interface{} {
+	if name, ok := e.Name(); ok {
+		if value, ok := f.variables[name]; ok {
+			return value
+		}
+	}
+	panic(issue.NewReported(LiteralUnresolvedVariable, issue.SeverityError, issue.H{`name`: e.NameOrIndex()}, e))
+}
+
+func (f *Folder) foldConcatenatedString(e *parser.ConcatenatedString) interface{} {
+	segments := e.Segments()
+	if len(segments) == 1 {
+		if ls, ok := segments[0].(*parser.LiteralString); ok {
+			return ls.Value()
+		}
+	}
+	result := make([]byte, 0, e.ByteLength())
+	for _, segment := range segments {
+		result = append(result, ToString(f.fold(segment))...)
+	}
+	return string(result)
+}
+
+func check(value interface{}, err error) interface{} {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+func notConstant(e parser.Expression) issue.Reported {
+	return issue.NewReported(LiteralNotConstant, issue.SeverityError, issue.H{`expression`: e}, e)
+}
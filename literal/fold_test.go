@@ -0,0 +1,130 @@
+package literal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestFoldArithmetic(t *testing.T) {
+	expectFold(t, `1 + 2 * 3`, int64(7))
+	expectFold(t, `7 / 2`, int64(3))
+	expectFold(t, `-7 / 2`, int64(-4))
+	expectFold(t, `-7 % 3`, int64(2))
+	expectFold(t, `1 + 0.5`, 1.5)
+	expectFold(t, `1 << 4`, int64(16))
+	expectFold(t, `-8 >> 1`, int64(-4))
+	expectFold(t, `1 >> 100`, int64(0))
+	expectFold(t, `9223372036854775806 + 1`, int64(9223372036854775807))
+	expectFold(t, `-(2 + 3)`, int64(-5))
+}
+
+func TestFoldCollections(t *testing.T) {
+	expectFold(t, `[1, 2] + [3]`, []interface{}{int64(1), int64(2), int64(3)})
+	expectFold(t, `[1, 2] + 3`, []interface{}{int64(1), int64(2), int64(3)})
+	expectFold(t, `[1, 2, 3, 2] - 2`, []interface{}{int64(1), int64(3)})
+	expectFold(t, `{a => 1, b => 2} + {b => 3}`, HashOf(`a`, int64(1), `b`, int64(3)))
+	expectFold(t, `{a => 1, b => 2} - [a]`, HashOf(`b`, int64(2)))
+	expectFold(t, `{b => 1, a => 2} + {c => 3, b => 4}`, HashOf(`b`, int64(4), `a`, int64(2), `c`, int64(3)))
+	expectFold(t, `"${{b => 1, a => 2}}"`, `{'b' => 1, 'a' => 2}`)
+	expectFold(t, `[1, 2, 3][1]`, int64(2))
+	expectFold(t, `[1, 2, 3][1, 2]`, []interface{}{int64(2), int64(3)})
+	expectFold(t, `{a => 1}[a]`, int64(1))
+	expectFold(t, `'hello'[1, 3]`, `ell`)
+	expectFold(t, `'héllo'[1]`, `é`)
+	expectFold(t, `'héllo'[-4, 2]`, `él`)
+}
+
+func TestFoldComparisons(t *testing.T) {
+	expectFold(t, `'A' == 'a'`, true)
+	expectFold(t, `1 == 1.0`, true)
+	expectFold(t, `[1, 'a'] == [1, 'A']`, true)
+	expectFold(t, `2 < 3 and 'b' > 'A'`, true)
+	expectFold(t, `!(1 != 1) or 1 > 2`, true)
+	expectFold(t, `'ell' in 'HELLO'`, true)
+	expectFold(t, `3 in [1, 2]`, false)
+	expectFold(t, `b in {a => 1, b => 2}`, true)
+}
+
+func TestFoldInterpolation(t *testing.T) {
+	expectFold(t, issue.Unindent(`
+    $x = 2
+    $y = [1, 'a']
+    "x=${x}, x+1=${$x + 1}, y=$y, h=${{a => 1.0}}"`),
+		`x=2, x+1=3, y=[1, 'a'], h={'a' => 1.0}`)
+}
+
+func TestFoldVariables(t *testing.T) {
+	expectFold(t, issue.Unindent(`
+    $a = 1
+    notice($a)
+    [$b, $c] = [$a + 1, $a + 2]
+    $a + $b + $c`), int64(6))
+
+	f := NewFolder()
+	f.SetVariable(`facts`, HashOf(`os`, `Linux`))
+	_, err := f.Fold(parse(t, `$x = "${facts['os']}-1"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.Variable(`x`); v != `Linux-1` {
+		t.Errorf(`expected 'Linux-1', got %v`, v)
+	}
+}
+
+func TestFoldFailures(t *testing.T) {
+	expectFoldError(t, `1 + $x`, LiteralUnresolvedVariable, 1, 5)
+	expectFoldError(t, `[1, 2] + [foo(1)]`, LiteralNotConstant, 1, 11)
+	expectFoldError(t, `1 + 'a'`, LiteralOperatorNotApplicable, 1, 1)
+	expectFoldError(t, `10 / (2 - 2)`, LiteralDivisionByZero, 1, 1)
+	expectFoldError(t, `9223372036854775807 + 1`, LiteralIntegerOverflow, 1, 1)
+	expectFoldError(t, `-9223372036854775807 - 2`, LiteralIntegerOverflow, 1, 1)
+	expectFoldError(t, `4611686018427387904 * 2`, LiteralIntegerOverflow, 1, 1)
+	expectFoldError(t, `1 << 100`, LiteralIntegerOverflow, 1, 1)
+	expectFoldError(t, `3 << 62`, LiteralIntegerOverflow, 1, 1)
+	expectFoldError(t, `{[1] => 2}`, LiteralIllegalHashKey, 1, 2)
+	expectFoldError(t, `[$a, $b] = [1]`, LiteralAssignmentCountMismatch, 1, 1)
+	expectFoldError(t, "$x = foo()\n\"${x}\"", LiteralUnresolvedVariable, 2, 2)
+	expectFoldError(t, "if true { $x = 1 }\n$x", LiteralUnresolvedVariable, 2, 1)
+}
+
+func expectFold(t *testing.T, source string, expected interface{}) {
+	t.Helper()
+	v, err := Fold(parse(t, source))
+	if err != nil {
+		t.Error(err.Error())
+		return
+	}
+	if !reflect.DeepEqual(expected, v) {
+		t.Errorf(`expected %#v, got %#v`, expected, v)
+	}
+}
+
+func expectFoldError(t *testing.T, source string, code issue.Code, line, pos int) {
+	t.Helper()
+	_, err := Fold(parse(t, source))
+	if err == nil {
+		t.Errorf(`expected %s, got no error`, code)
+		return
+	}
+	ri := err.(issue.Reported)
+	if ri.Code() != code {
+		t.Errorf(`expected %s, got %s`, code, ri.String())
+		return
+	}
+	loc := ri.Location()
+	if loc.Line() != line || loc.Pos() != pos {
+		t.Errorf(`expected %s at %d:%d, got %d:%d`, code, line, pos, loc.Line(), loc.Pos())
+	}
+}
+
+func parse(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(``, source, false)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return expr
+}
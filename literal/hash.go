@@ -0,0 +1,50 @@
+package literal
+
+// A Hash is the value of a Puppet hash. It keeps its entries in the order that their keys were
+// first inserted, which is the order used when iterating over the hash and when converting it to
+// a string. The keys must be hashable, i.e. they cannot be arrays or hashes.
+type Hash struct {
+	keys   []interface{}
+	values map[interface{}]interface{}
+}
+
+// NewHash returns an empty hash with room for the given number of entries
+func NewHash(capacity int) *Hash {
+	return &Hash{keys: make([]interface{}, 0, capacity), values: make(map[interface{}]interface{}, capacity)}
+}
+
+// HashOf returns a hash with the given alternating keys and values
+func HashOf(keysAndValues ...interface{}) *Hash {
+	h := NewHash(len(keysAndValues) / 2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		h.Put(keysAndValues[i], keysAndValues[i+1])
+	}
+	return h
+}
+
+// Put sets the value of the given key. A key that is already present keeps its position.
+func (h *Hash) Put(key, value interface{}) {
+	if _, ok := h.values[key]; !ok {
+		h.keys = append(h.keys, key)
+	}
+	h.values[key] = value
+}
+
+// Get returns the value of the given key and true, or nil and false when the key isn't present
+func (h *Hash) Get(key interface{}) (interface{}, bool) {
+	if !isHashable(key) {
+		return nil, false
+	}
+	v, ok := h.values[key]
+	return v, ok
+}
+
+// Keys returns the keys of the hash in insertion order. The returned slice must not be modified.
+func (h *Hash) Keys() []interface{} {
+	return h.keys
+}
+
+// Len returns the number of entries in the hash
+func (h *Hash) Len() int {
+	return len(h.keys)
+}
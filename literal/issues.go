@@ -0,0 +1,50 @@
+package literal
+
+import (
+	"github.com/lyraproj/issue/issue"
+)
+
+const (
+	LiteralAssignmentCountMismatch = `LITERAL_ASSIGNMENT_COUNT_MISMATCH`
+	LiteralDivisionByZero          = `LITERAL_DIVISION_BY_ZERO`
+	LiteralIllegalHashKey          = `LITERAL_ILLEGAL_HASH_KEY`
+	LiteralIllegalTypeArguments    = `LITERAL_ILLEGAL_TYPE_ARGUMENTS`
+	LiteralIllegalTypeExpression   = `LITERAL_ILLEGAL_TYPE_EXPRESSION`
+	LiteralIndexNotApplicable      = `LITERAL_INDEX_NOT_APPLICABLE`
+	LiteralIntegerOverflow         = `LITERAL_INTEGER_OVERFLOW`
+	LiteralNotConstant             = `LITERAL_NOT_CONSTANT`
+	LiteralOperatorNotApplicable   = `LITERAL_OPERATOR_NOT_APPLICABLE`
+	LiteralUnaryNotApplicable      = `LITERAL_UNARY_NOT_APPLICABLE`
+	LiteralUnknownType             = `LITERAL_UNKNOWN_TYPE`
+	LiteralUnresolvedVariable      = `LITERAL_UNRESOLVED_VARIABLE`
+)
+
+func init() {
+	issue.Hard(LiteralAssignmentCountMismatch, `Mismatched number of variables and values in multiple assignment. Expected %{expected} values, got %{actual}`)
+
+	issue.Hard(LiteralDivisionByZero, `Division by zero`)
+
+	issue.Hard2(LiteralIllegalHashKey, `%{type} cannot be used as a hash key`, issue.HF{`type`: issue.UcAnOrA})
+
+	issue.Hard(LiteralIllegalTypeArguments, `Illegal type arguments in %{type}`)
+
+	issue.Hard2(LiteralIllegalTypeExpression, `%{expression} is not a valid type`, issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard2(LiteralIndexNotApplicable, `The [] operator is not applicable to %{type} using key %{key}`,
+		issue.HF{`type`: issue.AnOrA})
+
+	issue.Hard(LiteralIntegerOverflow, `The result of operator '%{operator}' is out of the range of a 64-bit Integer`)
+
+	issue.Hard2(LiteralNotConstant, `%{expression} cannot be folded into a constant value`,
+		issue.HF{`expression`: issue.UcAnOrA})
+
+	issue.Hard2(LiteralOperatorNotApplicable, `Operator '%{operator}' is not applicable to %{left} and %{right}`,
+		issue.HF{`left`: issue.AnOrA, `right`: issue.AnOrA})
+
+	issue.Hard2(LiteralUnaryNotApplicable, `Operator '%{operator}' is not applicable to %{type}`,
+		issue.HF{`type`: issue.AnOrA})
+
+	issue.Hard(LiteralUnknownType, `Unknown type '%{type}'`)
+
+	issue.Hard(LiteralUnresolvedVariable, `Variable $%{name} does not refer to a constant value`)
+}
@@ -0,0 +1,576 @@
+package literal
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// The functions in this file operate on the values produced by Fold, i.e. nil (undef),
+// bool, int64, float64, string, parser.Default, []interface{}, and *Hash, and
+// also on the *regexp.Regexp and Type values used by evaluators. They follow the semantics of the
+// Puppet language and report errors as issue.Reported instances using the given location.
+
+// TypeName returns the name of the Puppet type that corresponds to the given value
+func TypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return `Undef`
+	case bool:
+		return `Boolean`
+	case int64:
+		return `Integer`
+	case float64:
+		return `Float`
+	case string:
+		return `String`
+	case parser.Default:
+		return `Default`
+	case []interface{}:
+		return `Array`
+	case *Hash:
+		return `Hash`
+	case *regexp.Regexp:
+		return `Regexp`
+	case Type:
+		return `Type`
+	default:
+		return `Any`
+	}
+}
+
+// IsTruthy returns false if the value is undef or false, and true otherwise
+func IsTruthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
+}
+
+// ToString converts the given value to a string in the same way as string interpolation
+func ToString(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ``
+	case string:
+		return v
+	default:
+		b := bytes.NewBufferString(``)
+		writeValue(b, v)
+		return b.String()
+	}
+}
+
+func writeValue(b *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		b.WriteString(`undef`)
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.ContainsAny(s, `.eIN`) {
+			s += `.0`
+		}
+		b.WriteString(s)
+	case string:
+		b.WriteByte('\'')
+		b.WriteString(strings.Replace(strings.Replace(v, `\`, `\\`, -1), `'`, `\'`, -1))
+		b.WriteByte('\'')
+	case parser.Default:
+		b.WriteString(`default`)
+	case *regexp.Regexp:
+		b.WriteByte('/')
+		b.WriteString(v.String())
+		b.WriteByte('/')
+	case Type:
+		b.WriteString(v.String())
+	case []interface{}:
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteString(`, `)
+			}
+			writeValue(b, e)
+		}
+		b.WriteByte(']')
+	case *Hash:
+		b.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				b.WriteString(`, `)
+			}
+			writeValue(b, k)
+			b.WriteString(` => `)
+			writeValue(b, v.values[k])
+		}
+		b.WriteByte('}')
+	default:
+		b.WriteString(issue.Label(v))
+	}
+}
+
+// Equals compares two values using the Puppet == operator semantics, i.e. strings are compared
+// without regard to case and integers and floats are compared by value
+func Equals(a, b interface{}) bool {
+	switch a := a.(type) {
+	case string:
+		if bs, ok := b.(string); ok {
+			return strings.EqualFold(a, bs)
+		}
+	case int64, float64:
+		if c, ok := compare(a, b); ok {
+			return c == 0
+		}
+	case []interface{}:
+		if bl, ok := b.([]interface{}); ok && len(a) == len(bl) {
+			for i, e := range a {
+				if !Equals(e, bl[i]) {
+					return false
+				}
+			}
+			return true
+		}
+	case *Hash:
+		if bh, ok := b.(*Hash); ok && a.Len() == bh.Len() {
+			for k, v := range a.values {
+				if bv, ok := bh.values[k]; !(ok && Equals(v, bv)) {
+					return false
+				}
+			}
+			return true
+		}
+	default:
+		return a == b
+	}
+	return false
+}
+
+// Compare applies one of the comparison operators ==, !=, <, <=, >, or >= to the given values
+func Compare(op string, a, b interface{}, location issue.Location) (bool, error) {
+	switch op {
+	case `==`:
+		return Equals(a, b), nil
+	case `!=`:
+		return !Equals(a, b), nil
+	}
+	c, ok := compare(a, b)
+	if !ok {
+		return false, operatorNotApplicable(op, a, b, location)
+	}
+	switch op {
+	case `<`:
+		return c < 0, nil
+	case `<=`:
+		return c <= 0, nil
+	case `>`:
+		return c > 0, nil
+	default:
+		return c >= 0, nil
+	}
+}
+
+// compare compares numbers with numbers and strings with strings. Strings are compared without
+// regard to case
+func compare(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return compareInts(a, b), true
+		case float64:
+			return compareFloats(float64(a), b), true
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return compareFloats(a, float64(b)), true
+		case float64:
+			return compareFloats(a, b), true
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(strings.ToLower(a), strings.ToLower(b)), true
+		}
+	}
+	return 0, false
+}
+
+func compareInts(a, b int64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// In applies the Puppet 'in' operator. A string is searched for as a substring without regard
+// to case, an array is searched for an equal element, and a hash is searched for an equal key.
+func In(a, b interface{}) bool {
+	switch b := b.(type) {
+	case string:
+		if as, ok := a.(string); ok {
+			return strings.Contains(strings.ToLower(b), strings.ToLower(as))
+		}
+	case []interface{}:
+		for _, e := range b {
+			if Equals(a, e) {
+				return true
+			}
+		}
+	case *Hash:
+		for _, k := range b.keys {
+			if Equals(a, k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Arithmetic applies one of the arithmetic operators +, -, *, /, %, <<, or >> to the given values.
+func Arithmetic(op string, a, b interface{}, location issue.Location) (interface{}, error) {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return intArithmetic(op, a, b, location)
+		case float64:
+			return floatArithmetic(op, float64(a), b, location)
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return floatArithmetic(op, a, float64(b), location)
+		case float64:
+			return floatArithmetic(op, a, b, location)
+		}
+	case []interface{}:
+		switch op {
+		case `+`:
+			switch b := b.(type) {
+			case []interface{}:
+				return concat(a, b), nil
+			case *Hash:
+				pairs := make([]interface{}, 0, b.Len())
+				for _, k := range b.keys {
+					pairs = append(pairs, []interface{}{k, b.values[k]})
+				}
+				return concat(a, pairs), nil
+			default:
+				return concat(a, []interface{}{b}), nil
+			}
+		case `-`:
+			remove, ok := b.([]interface{})
+			if !ok {
+				remove = []interface{}{b}
+			}
+			result := make([]interface{}, 0, len(a))
+			for _, e := range a {
+				if !In(e, remove) {
+					result = append(result, e)
+				}
+			}
+			return result, nil
+		case `<<`:
+			return concat(a, []interface{}{b}), nil
+		}
+	case *Hash:
+		switch op {
+		case `+`:
+			if bh, ok := b.(*Hash); ok {
+				result := NewHash(a.Len() + bh.Len())
+				for _, k := range a.keys {
+					result.Put(k, a.values[k])
+				}
+				for _, k := range bh.keys {
+					result.Put(k, bh.values[k])
+				}
+				return result, nil
+			}
+		case `-`:
+			var remove []interface{}
+			switch b := b.(type) {
+			case []interface{}:
+				remove = b
+			case *Hash:
+				remove = b.keys
+			default:
+				remove = []interface{}{b}
+			}
+			result := NewHash(a.Len())
+			for _, k := range a.keys {
+				if !In(k, remove) {
+					result.Put(k, a.values[k])
+				}
+			}
+			return result, nil
+		}
+	}
+	return nil, operatorNotApplicable(op, a, b, location)
+}
+
+func concat(a, b []interface{}) []interface{} {
+	result := make([]interface{}, 0, len(a)+len(b))
+	return append(append(result, a...), b...)
+}
+
+// intArithmetic applies the operator to two integers. An error is returned when the result doesn't fit
+// in 64 bits.
+func intArithmetic(op string, a, b int64, location issue.Location) (interface{}, error) {
+	switch op {
+	case `+`:
+		if b > 0 && a > math.MaxInt64-b || b < 0 && a < math.MinInt64-b {
+			return nil, integerOverflow(op, location)
+		}
+		return a + b, nil
+	case `-`:
+		if b < 0 && a > math.MaxInt64+b || b > 0 && a < math.MinInt64+b {
+			return nil, integerOverflow(op, location)
+		}
+		return a - b, nil
+	case `*`:
+		r := a * b
+		if a != 0 && (r/a != b || a == -1 && b == math.MinInt64) {
+			return nil, integerOverflow(op, location)
+		}
+		return r, nil
+	case `/`, `%`:
+		if b == 0 {
+			return nil, issue.NewReported(LiteralDivisionByZero, issue.SeverityError, issue.NoArgs, location)
+		}
+		if op == `/` && a == math.MinInt64 && b == -1 {
+			return nil, integerOverflow(op, location)
+		}
+		// Integer division and modulo rounds towards negative infinity
+		q := a / b
+		m := a % b
+		if m != 0 && (m < 0) != (b < 0) {
+			q--
+			m += b
+		}
+		if op == `/` {
+			return q, nil
+		}
+		return m, nil
+	case `<<`:
+		if b < 0 {
+			return shiftRight(a, b), nil
+		}
+		return shiftLeft(op, a, b, location)
+	case `>>`:
+		if b < 0 {
+			return shiftLeft(op, a, b, location)
+		}
+		return shiftRight(a, b), nil
+	}
+	return nil, operatorNotApplicable(op, a, b, location)
+}
+
+// shiftLeft shifts the integer to the left by the absolute value of the given count. An error is returned
+// when bits other than the sign bit are shifted out.
+func shiftLeft(op string, a, count int64, location issue.Location) (interface{}, error) {
+	if count < 0 {
+		count = -count
+	}
+	if a == 0 {
+		return a, nil
+	}
+	if count >= 64 {
+		return nil, integerOverflow(op, location)
+	}
+	r := a << uint64(count)
+	if r>>uint64(count) != a {
+		return nil, integerOverflow(op, location)
+	}
+	return r, nil
+}
+
+// shiftRight shifts the integer to the right by the absolute value of the given count, preserving its sign
+func shiftRight(a, count int64) int64 {
+	if count < 0 {
+		count = -count
+	}
+	if count >= 64 {
+		count = 63
+	}
+	return a >> uint64(count)
+}
+
+func floatArithmetic(op string, a, b float64, location issue.Location) (interface{}, error) {
+	switch op {
+	case `+`:
+		return a + b, nil
+	case `-`:
+		return a - b, nil
+	case `*`:
+		return a * b, nil
+	case `/`:
+		if b == 0.0 {
+			return nil, issue.NewReported(LiteralDivisionByZero, issue.SeverityError, issue.NoArgs, location)
+		}
+		return a / b, nil
+	case `%`:
+		if b == 0.0 {
+			return nil, issue.NewReported(LiteralDivisionByZero, issue.SeverityError, issue.NoArgs, location)
+		}
+		m := math.Mod(a, b)
+		if m != 0 && (m < 0) != (b < 0) {
+			m += b
+		}
+		return m, nil
+	}
+	return nil, operatorNotApplicable(op, a, b, location)
+}
+
+// Negate applies the unary minus operator to the given value
+func Negate(v interface{}, location issue.Location) (interface{}, error) {
+	switch v := v.(type) {
+	case int64:
+		if v == math.MinInt64 {
+			return nil, integerOverflow(`-`, location)
+		}
+		return -v, nil
+	case float64:
+		return -v, nil
+	}
+	return nil, issue.NewReported(LiteralUnaryNotApplicable, issue.SeverityError, issue.H{`operator`: `-`, `type`: TypeName(v)}, location)
+}
+
+// Access applies the [] operator to the given value. Arrays and strings accept an index or a start index
+// and a count, where a negative index counts from the end. Hashes accept one or more keys. Accessing
+// something that doesn't exist yields undef.
+func Access(v interface{}, keys []interface{}, location issue.Location) (interface{}, error) {
+	switch v := v.(type) {
+	case []interface{}:
+		if start, count, ok := accessRange(len(v), keys); ok {
+			if count < 0 {
+				if start < 0 || start >= len(v) {
+					return nil, nil
+				}
+				return v[start], nil
+			}
+			result := make([]interface{}, count)
+			copy(result, v[start:start+count])
+			return result, nil
+		}
+	case string:
+		// Strings are indexed by character, not by byte
+		runes := []rune(v)
+		if start, count, ok := accessRange(len(runes), keys); ok {
+			if count < 0 {
+				if start < 0 || start >= len(runes) {
+					return nil, nil
+				}
+				count = 1
+			}
+			return string(runes[start : start+count]), nil
+		}
+	case *Hash:
+		if len(keys) == 1 {
+			e, _ := v.Get(keys[0])
+			return e, nil
+		}
+		if len(keys) > 1 {
+			result := make([]interface{}, 0, len(keys))
+			for _, k := range keys {
+				if e, ok := v.Get(k); ok {
+					result = append(result, e)
+				}
+			}
+			return result, nil
+		}
+	}
+	var key interface{}
+	if len(keys) == 1 {
+		key = keys[0]
+	} else {
+		key = keys
+	}
+	return nil, issue.NewReported(LiteralIndexNotApplicable, issue.SeverityError, issue.H{`type`: TypeName(v), `key`: ToString(key)}, location)
+}
+
+// accessRange validates the keys for array or string access. A count < 0 is returned when
+// the keys consisted of a single index. Otherwise, start and count are adjusted so that they
+// are within the bounds of the given size.
+func accessRange(size int, keys []interface{}) (start int, count int, ok bool) {
+	if len(keys) < 1 || len(keys) > 2 {
+		return
+	}
+	var ix int64
+	if ix, ok = keys[0].(int64); !ok {
+		return
+	}
+	start = int(ix)
+	if start < 0 {
+		start += size
+	}
+	if len(keys) == 1 {
+		count = -1
+		return
+	}
+
+	var cnt int64
+	if cnt, ok = keys[1].(int64); !ok {
+		return
+	}
+	count = int(cnt)
+	if count < 0 {
+		// Negative count denotes an end index, counting from the end
+		count = size + count + 1 - start
+	}
+	if start < 0 {
+		count += start
+		start = 0
+	}
+	if start > size {
+		start = size
+	}
+	if count < 0 {
+		count = 0
+	}
+	if start+count > size {
+		count = size - start
+	}
+	return
+}
+
+func isHashable(v interface{}) bool {
+	switch v.(type) {
+	case []interface{}, *Hash:
+		return false
+	default:
+		return true
+	}
+}
+
+func integerOverflow(op string, location issue.Location) issue.Reported {
+	return issue.NewReported(LiteralIntegerOverflow, issue.SeverityError, issue.H{`operator`: op}, location)
+}
+
+func operatorNotApplicable(op string, a, b interface{}, location issue.Location) issue.Reported {
+	return issue.NewReported(LiteralOperatorNotApplicable, issue.SeverityError, issue.H{`operator`: op, `left`: TypeName(a), `right`: TypeName(b)}, location)
+}
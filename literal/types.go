@@ -0,0 +1,550 @@
+package literal
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// A Type is the result of resolving a Puppet type expression such as `Optional[Array[String]]`. Only the
+// types that can be used to describe the values produced by Fold are supported.
+type Type interface {
+	// IsInstance returns true if the given value is an instance of the type
+	IsInstance(v interface{}) bool
+
+	// String returns the string representation of the type
+	String() string
+}
+
+type puppetType struct {
+	name     string
+	instance func(v interface{}) bool
+}
+
+func (t *puppetType) IsInstance(v interface{}) bool {
+	return t.instance(v)
+}
+
+func (t *puppetType) String() string {
+	return t.name
+}
+
+var (
+	anyType    = &puppetType{`Any`, func(v interface{}) bool { return true }}
+	undefType  = &puppetType{`Undef`, func(v interface{}) bool { return v == nil }}
+	scalarType = &puppetType{`Scalar`, isScalar}
+	dataType   = &puppetType{`Data`, isData}
+)
+
+// ResolveType resolves the given type expression into a Type. The optional aliases map is keyed by lower case type
+// names and used when resolving references to type aliases. An error is returned when the expression isn't a
+// known type or when its type parameters are illegal.
+func ResolveType(e parser.Expression, aliases map[string]parser.Expression) (t Type, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if ri, ok := r.(issue.Reported); ok {
+				err = ri
+			} else {
+				panic(r)
+			}
+		}
+	}()
+	tr := &typeResolver{aliases: aliases, resolved: make(map[string]Type)}
+	t = tr.resolve(e)
+	return
+}
+
+type typeResolver struct {
+	aliases  map[string]parser.Expression
+	resolved map[string]Type
+}
+
+func (tr *typeResolver) resolve(e parser.Expression) Type {
+	switch e := e.(type) {
+	case *parser.QualifiedReference:
+		return tr.resolveName(e)
+	case *parser.AccessExpression:
+		if qr, ok := e.Operand().(*parser.QualifiedReference); ok {
+			return tr.resolveParameterized(qr, e)
+		}
+	}
+	panic(issue.NewReported(LiteralIllegalTypeExpression, issue.SeverityError, issue.H{`expression`: e}, e))
+}
+
+func (tr *typeResolver) resolveName(qr *parser.QualifiedReference) Type {
+	switch strings.ToLower(qr.Name()) {
+	case `any`:
+		return anyType
+	case `undef`:
+		return undefType
+	case `notundef`:
+		return &puppetType{`NotUndef`, func(v interface{}) bool { return v != nil }}
+	case `default`:
+		return &puppetType{`Default`, func(v interface{}) bool { _, ok := v.(parser.Default); return ok }}
+	case `boolean`:
+		return &puppetType{`Boolean`, func(v interface{}) bool { _, ok := v.(bool); return ok }}
+	case `scalar`:
+		return scalarType
+	case `data`:
+		return dataType
+	case `string`:
+		return stringType(`String`, 0, math.MaxInt64)
+	case `integer`:
+		return integerType(`Integer`, math.MinInt64, math.MaxInt64)
+	case `float`:
+		return floatType(`Float`, -math.MaxFloat64, math.MaxFloat64)
+	case `numeric`:
+		return &puppetType{`Numeric`, isNumeric}
+	case `regexp`:
+		return &puppetType{`Regexp`, func(v interface{}) bool { _, ok := v.(*regexp.Regexp); return ok }}
+	case `type`:
+		return &puppetType{`Type`, func(v interface{}) bool { _, ok := v.(Type); return ok }}
+	case `collection`:
+		return collectionType(`Collection`, 0, math.MaxInt64)
+	case `array`:
+		return arrayType(`Array`, anyType, 0, math.MaxInt64)
+	case `hash`:
+		return hashType(`Hash`, anyType, anyType, 0, math.MaxInt64)
+	case `optional`:
+		return anyType
+	}
+	return tr.resolveAlias(qr)
+}
+
+func (tr *typeResolver) resolveAlias(qr *parser.QualifiedReference) Type {
+	name := strings.ToLower(qr.Name())
+	if t, ok := tr.resolved[name]; ok {
+		if t == nil {
+			// Alias is currently being resolved. Recursive aliases are not supported.
+			panic(issue.NewReported(LiteralIllegalTypeExpression, issue.SeverityError, issue.H{`expression`: qr}, qr))
+		}
+		return t
+	}
+	if te, ok := tr.aliases[name]; ok {
+		tr.resolved[name] = nil
+		at := tr.resolve(te)
+		t := &puppetType{qr.Name(), at.IsInstance}
+		tr.resolved[name] = t
+		return t
+	}
+	panic(issue.NewReported(LiteralUnknownType, issue.SeverityError, issue.H{`type`: qr.Name()}, qr))
+}
+
+func (tr *typeResolver) resolveParameterized(qr *parser.QualifiedReference, e *parser.AccessExpression) Type {
+	name := e.String()
+	params := e.Keys()
+	switch strings.ToLower(qr.Name()) {
+	case `string`:
+		min, max := tr.intRange(e, params, 0)
+		return stringType(name, min, max)
+	case `integer`:
+		min, max := tr.intRange(e, params, math.MinInt64)
+		return integerType(name, min, max)
+	case `float`:
+		min, max := tr.floatRange(e, params)
+		return floatType(name, min, max)
+	case `collection`:
+		min, max := tr.intRange(e, params, 0)
+		return collectionType(name, min, max)
+	case `array`:
+		if len(params) > 0 {
+			min, max := tr.intRange(e, params[1:], 0)
+			return arrayType(name, tr.resolve(params[0]), min, max)
+		}
+	case `hash`:
+		if len(params) > 1 {
+			min, max := tr.intRange(e, params[2:], 0)
+			return hashType(name, tr.resolve(params[0]), tr.resolve(params[1]), min, max)
+		}
+	case `optional`:
+		if len(params) == 1 {
+			t := tr.resolveOrString(params[0])
+			return &puppetType{name, func(v interface{}) bool { return v == nil || t.IsInstance(v) }}
+		}
+	case `notundef`:
+		if len(params) == 1 {
+			t := tr.resolveOrString(params[0])
+			return &puppetType{name, func(v interface{}) bool { return v != nil && t.IsInstance(v) }}
+		}
+	case `variant`:
+		if len(params) > 0 {
+			ts := make([]Type, len(params))
+			for i, p := range params {
+				ts[i] = tr.resolve(p)
+			}
+			return &puppetType{name, func(v interface{}) bool {
+				for _, t := range ts {
+					if t.IsInstance(v) {
+						return true
+					}
+				}
+				return false
+			}}
+		}
+	case `enum`:
+		if len(params) > 0 {
+			strs := make([]string, len(params))
+			for i, p := range params {
+				strs[i] = tr.stringParam(e, p)
+			}
+			return enumType(name, strs)
+		}
+	case `pattern`:
+		if len(params) > 0 {
+			rxs := make([]*regexp.Regexp, len(params))
+			for i, p := range params {
+				rxs[i] = tr.regexpParam(e, p)
+			}
+			return &puppetType{name, func(v interface{}) bool {
+				if s, ok := v.(string); ok {
+					for _, rx := range rxs {
+						if rx.MatchString(s) {
+							return true
+						}
+					}
+				}
+				return false
+			}}
+		}
+	case `tuple`:
+		if len(params) > 0 {
+			ts := make([]Type, len(params))
+			for i, p := range params {
+				ts[i] = tr.resolve(p)
+			}
+			return &puppetType{name, func(v interface{}) bool {
+				a, ok := v.([]interface{})
+				if !ok || len(a) != len(ts) {
+					return false
+				}
+				for i, t := range ts {
+					if !t.IsInstance(a[i]) {
+						return false
+					}
+				}
+				return true
+			}}
+		}
+	case `struct`:
+		if len(params) == 1 {
+			if h, ok := params[0].(*parser.LiteralHash); ok {
+				return tr.structType(name, h)
+			}
+		}
+	default:
+		// Type aliases cannot be parameterized
+		tr.resolveAlias(qr)
+	}
+	panic(illegalTypeArguments(e))
+}
+
+// resolveOrString resolves the given expression as a type unless it is a string in which case
+// the type will be an Enum of that string.
+func (tr *typeResolver) resolveOrString(e parser.Expression) Type {
+	switch e.(type) {
+	case *parser.QualifiedReference, *parser.AccessExpression:
+		return tr.resolve(e)
+	}
+	s := tr.stringParam(e, e)
+	return enumType(`Enum['`+s+`']`, []string{s})
+}
+
+func (tr *typeResolver) structType(name string, h *parser.LiteralHash) Type {
+	type member struct {
+		key      string
+		optional bool
+		typ      Type
+	}
+	entries := h.Entries()
+	members := make([]member, len(entries))
+	for i, entry := range entries {
+		ke := entry.(*parser.KeyedEntry)
+		m := member{typ: tr.resolve(ke.Value())}
+		if ae, ok := ke.Key().(*parser.AccessExpression); ok {
+			if qr, ok := ae.Operand().(*parser.QualifiedReference); ok && strings.EqualFold(qr.Name(), `optional`) && len(ae.Keys()) == 1 {
+				m.optional = true
+				m.key = tr.stringParam(ae, ae.Keys()[0])
+				members[i] = m
+				continue
+			}
+		}
+		m.key = tr.stringParam(h, ke.Key())
+		members[i] = m
+	}
+	return &puppetType{name, func(v interface{}) bool {
+		hv, ok := v.(*Hash)
+		if !ok {
+			return false
+		}
+		for _, m := range members {
+			mv, found := hv.Get(m.key)
+			if !found {
+				if m.optional || m.typ.IsInstance(nil) {
+					continue
+				}
+				return false
+			}
+			if !(m.typ.IsInstance(mv) || m.optional && mv == nil) {
+				return false
+			}
+		}
+		for _, k := range hv.Keys() {
+			found := false
+			for _, m := range members {
+				if k == m.key {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return true
+	}}
+}
+
+func (tr *typeResolver) intRange(e *parser.AccessExpression, params []parser.Expression, min int64) (int64, int64) {
+	max := int64(math.MaxInt64)
+	switch len(params) {
+	case 2:
+		max = tr.intParam(e, params[1], max)
+		fallthrough
+	case 1:
+		min = tr.intParam(e, params[0], min)
+	case 0:
+	default:
+		panic(illegalTypeArguments(e))
+	}
+	return min, max
+}
+
+func (tr *typeResolver) intParam(e *parser.AccessExpression, p parser.Expression, dflt int64) int64 {
+	switch v := tr.fold(e, p).(type) {
+	case int64:
+		return v
+	case parser.Default:
+		return dflt
+	}
+	panic(illegalTypeArguments(e))
+}
+
+func (tr *typeResolver) floatRange(e *parser.AccessExpression, params []parser.Expression) (float64, float64) {
+	min := -math.MaxFloat64
+	max := math.MaxFloat64
+	switch len(params) {
+	case 2:
+		max = tr.floatParam(e, params[1], max)
+		fallthrough
+	case 1:
+		min = tr.floatParam(e, params[0], min)
+	case 0:
+	default:
+		panic(illegalTypeArguments(e))
+	}
+	return min, max
+}
+
+func (tr *typeResolver) floatParam(e *parser.AccessExpression, p parser.Expression, dflt float64) float64 {
+	switch v := tr.fold(e, p).(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case parser.Default:
+		return dflt
+	}
+	panic(illegalTypeArguments(e))
+}
+
+func (tr *typeResolver) stringParam(e parser.Expression, p parser.Expression) string {
+	if s, ok := tr.fold(e, p).(string); ok {
+		return s
+	}
+	panic(illegalTypeArguments(e))
+}
+
+func (tr *typeResolver) regexpParam(e parser.Expression, p parser.Expression) *regexp.Regexp {
+	var pattern string
+	if re, ok := p.(*parser.RegexpExpression); ok {
+		pattern = re.Value().(string)
+	} else {
+		pattern = tr.stringParam(e, p)
+	}
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(illegalTypeArguments(e))
+	}
+	return rx
+}
+
+func (tr *typeResolver) fold(e parser.Expression, p parser.Expression) interface{} {
+	v, err := Fold(p)
+	if err != nil {
+		panic(illegalTypeArguments(e))
+	}
+	return v
+}
+
+func illegalTypeArguments(e parser.Expression) issue.Reported {
+	return issue.NewReported(LiteralIllegalTypeArguments, issue.SeverityError, issue.H{`type`: e.String()}, e)
+}
+
+func stringType(name string, min, max int64) Type {
+	return &puppetType{name, func(v interface{}) bool {
+		if s, ok := v.(string); ok {
+			l := int64(utf8.RuneCountInString(s))
+			return min <= l && l <= max
+		}
+		return false
+	}}
+}
+
+func integerType(name string, min, max int64) Type {
+	return &puppetType{name, func(v interface{}) bool {
+		i, ok := v.(int64)
+		return ok && min <= i && i <= max
+	}}
+}
+
+func floatType(name string, min, max float64) Type {
+	return &puppetType{name, func(v interface{}) bool {
+		f, ok := v.(float64)
+		return ok && min <= f && f <= max
+	}}
+}
+
+func collectionType(name string, min, max int64) Type {
+	return &puppetType{name, func(v interface{}) bool {
+		switch v := v.(type) {
+		case []interface{}:
+			return min <= int64(len(v)) && int64(len(v)) <= max
+		case *Hash:
+			return min <= int64(v.Len()) && int64(v.Len()) <= max
+		}
+		return false
+	}}
+}
+
+func arrayType(name string, elem Type, min, max int64) Type {
+	return &puppetType{name, func(v interface{}) bool {
+		a, ok := v.([]interface{})
+		if !ok || int64(len(a)) < min || int64(len(a)) > max {
+			return false
+		}
+		for _, e := range a {
+			if !elem.IsInstance(e) {
+				return false
+			}
+		}
+		return true
+	}}
+}
+
+func hashType(name string, key, value Type, min, max int64) Type {
+	return &puppetType{name, func(v interface{}) bool {
+		h, ok := v.(*Hash)
+		if !ok || int64(h.Len()) < min || int64(h.Len()) > max {
+			return false
+		}
+		for _, k := range h.Keys() {
+			if e, _ := h.Get(k); !(key.IsInstance(k) && value.IsInstance(e)) {
+				return false
+			}
+		}
+		return true
+	}}
+}
+
+func enumType(name string, strs []string) Type {
+	return &puppetType{name, func(v interface{}) bool {
+		if s, ok := v.(string); ok {
+			for _, es := range strs {
+				if s == es {
+					return true
+				}
+			}
+		}
+		return false
+	}}
+}
+
+func isNumeric(v interface{}) bool {
+	switch v.(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case string, int64, float64, bool, *regexp.Regexp:
+		return true
+	}
+	return false
+}
+
+func isData(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		for _, e := range v {
+			if !isData(e) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		for _, k := range v.Keys() {
+			if _, ok := k.(string); !ok || !isData(v.values[k]) {
+				return false
+			}
+		}
+		return true
+	}
+	return isScalar(v) && !isRegexp(v)
+}
+
+func isRegexp(v interface{}) bool {
+	_, ok := v.(*regexp.Regexp)
+	return ok
+}
+
+// TypeString returns a string representation of the given values type that is suitable
+// for use in error messages, e.g. String, Integer, or Array[String].
+func TypeString(v interface{}) string {
+	b := bytes.NewBufferString(``)
+	writeTypeString(b, v)
+	return b.String()
+}
+
+func writeTypeString(b *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case []interface{}:
+		b.WriteString(`Array`)
+		if len(v) > 0 {
+			b.WriteByte('[')
+			writeTypeString(b, v[0])
+			b.WriteByte(']')
+		}
+	case *Hash:
+		b.WriteString(`Hash`)
+		if v.Len() > 0 {
+			k := v.keys[0]
+			b.WriteByte('[')
+			writeTypeString(b, k)
+			b.WriteString(`, `)
+			writeTypeString(b, v.values[k])
+			b.WriteByte(']')
+		}
+	default:
+		b.WriteString(TypeName(v))
+	}
+}
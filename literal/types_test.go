@@ -0,0 +1,193 @@
+package literal
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestResolveTypeInstances(t *testing.T) {
+	for _, test := range []struct {
+		typ      string
+		value    string
+		instance bool
+	}{
+		{`Any`, `undef`, true},
+		{`Undef`, `undef`, true},
+		{`Undef`, `1`, false},
+		{`NotUndef`, `undef`, false},
+		{`Default`, `default`, true},
+		{`Boolean`, `false`, true},
+		{`Boolean`, `'false'`, false},
+		{`String`, `'héllo'`, true},
+		{`String[1, 5]`, `'héllo'`, true},
+		{`String[1, 4]`, `'héllo'`, false},
+		{`String[1]`, `''`, false},
+		{`Integer`, `1`, true},
+		{`Integer`, `1.0`, false},
+		{`Integer[0, default]`, `9223372036854775807`, true},
+		{`Integer[0]`, `-1`, false},
+		{`Float[0.5, 1]`, `1.0`, true},
+		{`Float[0.5, 1]`, `0.25`, false},
+		{`Numeric`, `0.25`, true},
+		{`Scalar`, `'a'`, true},
+		{`Scalar`, `[1]`, false},
+		{`Data`, `{a => [1, 'b', undef]}`, true},
+		{`Data`, `{1 => 'a'}`, false},
+		{`Collection[2]`, `{a => 1}`, false},
+		{`Collection[1, 2]`, `[1, 2]`, true},
+		{`Array`, `[1, 'a']`, true},
+		{`Array`, `[default, undef]`, true},
+		{`Array[Integer, 1]`, `[]`, false},
+		{`Array[Integer]`, `[1, 'a']`, false},
+		{`Hash`, `{a => 1}`, true},
+		{`Hash`, `{1 => default}`, true},
+		{`Hash[String, Integer]`, `{a => 1, b => 'c'}`, false},
+		{`Hash[Integer, String, 1, 1]`, `{1 => 'a'}`, true},
+		{`Optional[String]`, `undef`, true},
+		{`Optional[String]`, `1`, false},
+		{`Optional['a']`, `'a'`, true},
+		{`NotUndef[Integer]`, `1`, true},
+		{`Variant[Integer, String]`, `'a'`, true},
+		{`Variant[Integer, String]`, `1.0`, false},
+		{`Enum[a, b]`, `'b'`, true},
+		{`Enum[a, b]`, `'c'`, false},
+		{`Pattern[/^a/, 'b$']`, `'ab'`, true},
+		{`Pattern[/^a/, 'b$']`, `'ba'`, false},
+		{`Tuple[Integer, String]`, `[1, 'a']`, true},
+		{`Tuple[Integer, String]`, `[1]`, false},
+		{`Struct[{a => Integer, Optional[b] => String}]`, `{a => 1}`, true},
+		{`Struct[{a => Integer, Optional[b] => String}]`, `{a => 1, b => undef}`, true},
+		{`Struct[{a => Integer, Optional[b] => String}]`, `{b => 'x'}`, false},
+		{`Struct[{a => Integer}]`, `{a => 1, c => 2}`, false},
+		{`Struct[{a => Optional[Integer]}]`, `{}`, true},
+	} {
+		typ, err := ResolveType(parseExpression(t, test.typ), nil)
+		if err != nil {
+			t.Errorf(`%s: %s`, test.typ, err.Error())
+			continue
+		}
+		if typ.String() != test.typ {
+			t.Errorf(`expected type string '%s', got '%s'`, test.typ, typ.String())
+		}
+		value, err := Fold(parseExpression(t, test.value))
+		if err != nil {
+			t.Errorf(`%s: %s`, test.value, err.Error())
+			continue
+		}
+		if typ.IsInstance(value) != test.instance {
+			t.Errorf(`expected %s.IsInstance(%s) to be %t`, test.typ, test.value, test.instance)
+		}
+	}
+
+	// Regular expressions and types cannot be folded
+	rx := regexp.MustCompile(`a`)
+	for source, instance := range map[string]bool{`Regexp`: true, `Scalar`: true, `Data`: false, `Type`: false} {
+		if typ, err := ResolveType(parseExpression(t, source), nil); err != nil || typ.IsInstance(rx) != instance {
+			t.Errorf(`expected %s.IsInstance(/a/) to be %t`, source, instance)
+		}
+	}
+	for _, test := range []struct {
+		typ      string
+		value    interface{}
+		instance bool
+	}{
+		{`Array`, []interface{}{rx}, true},
+		{`Array[Data]`, []interface{}{rx}, false},
+		{`Hash`, HashOf(rx, rx), true},
+		{`Hash[String, Data]`, HashOf(`a`, rx), false},
+	} {
+		if typ, err := ResolveType(parseExpression(t, test.typ), nil); err != nil || typ.IsInstance(test.value) != test.instance {
+			t.Errorf(`expected %s.IsInstance(%v) to be %t`, test.typ, test.value, test.instance)
+		}
+	}
+	typ, _ := ResolveType(parseExpression(t, `Type`), nil)
+	if !typ.IsInstance(typ) {
+		t.Errorf(`expected Type to be an instance of Type`)
+	}
+}
+
+func TestResolveTypeAliases(t *testing.T) {
+	aliases := map[string]parser.Expression{
+		`names`: parseExpression(t, `Array[Name]`),
+		`name`:  parseExpression(t, `String[1]`),
+		`loop`:  parseExpression(t, `Array[Loop]`),
+	}
+	typ, err := ResolveType(parseExpression(t, `Optional[Names]`), aliases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !typ.IsInstance([]interface{}{`a`}) || typ.IsInstance([]interface{}{``}) {
+		t.Errorf(`expected %s to accept names only`, typ)
+	}
+
+	_, err = ResolveType(parseExpression(t, `Loop`), aliases)
+	expectTypeError(t, err, LiteralIllegalTypeExpression)
+	_, err = ResolveType(parseExpression(t, `Name[1]`), aliases)
+	expectTypeError(t, err, LiteralIllegalTypeArguments)
+}
+
+func TestResolveTypeErrors(t *testing.T) {
+	for source, code := range map[string]issue.Code{
+		`Foo`:              LiteralUnknownType,
+		`Array[Foo]`:       LiteralUnknownType,
+		`$x`:               LiteralIllegalTypeExpression,
+		`Array[1]`:         LiteralIllegalTypeExpression,
+		`Integer[a]`:       LiteralIllegalTypeArguments,
+		`Integer[1, 2, 3]`: LiteralIllegalTypeArguments,
+		`Float[$x]`:        LiteralIllegalTypeArguments,
+		`Pattern['(']`:     LiteralIllegalTypeArguments,
+		`Optional[1]`:      LiteralIllegalTypeArguments,
+		`Struct[Integer]`:  LiteralIllegalTypeArguments,
+		`Variant[]`:        LiteralIllegalTypeArguments,
+		`Hash[String]`:     LiteralIllegalTypeArguments,
+	} {
+		_, err := ResolveType(parseExpression(t, source), nil)
+		expectTypeError(t, err, code)
+	}
+}
+
+func TestTypeString(t *testing.T) {
+	for source, expected := range map[string]string{
+		`'a'`:          `String`,
+		`1`:            `Integer`,
+		`[]`:           `Array`,
+		`[[1], 'a']`:   `Array[Array[Integer]]`,
+		`{}`:           `Hash`,
+		`{a => [1.0]}`: `Hash[String, Array[Float]]`,
+	} {
+		value, err := Fold(parseExpression(t, source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := TypeString(value); s != expected {
+			t.Errorf(`expected the type string of %s to be %s, got %s`, source, expected, s)
+		}
+	}
+}
+
+// parseExpression parses the given source and returns its only statement
+func parseExpression(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	expr := parse(t, source)
+	if program, ok := expr.(*parser.Program); ok {
+		expr = program.Body()
+	}
+	if block, ok := expr.(*parser.BlockExpression); ok && len(block.Statements()) == 1 {
+		expr = block.Statements()[0]
+	}
+	return expr
+}
+
+func expectTypeError(t *testing.T, err error, code issue.Code) {
+	t.Helper()
+	if err == nil {
+		t.Errorf(`expected %s, got no error`, code)
+		return
+	}
+	if ri, ok := err.(issue.Reported); !ok || ri.Code() != code {
+		t.Errorf(`expected %s, got %s`, code, err.Error())
+	}
+}
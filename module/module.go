@@ -0,0 +1,68 @@
+// Package module parses the Puppet source files of a module directory.
+package module
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Sources are the parsed .pp files of a module
+type Sources struct {
+	files []string
+	exprs map[string]parser.Expression
+}
+
+// New returns the sources of the given parsed files, keyed by path
+func New(exprs map[string]parser.Expression) *Sources {
+	files := make([]string, 0, len(exprs))
+	for file := range exprs {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return &Sources{files: files, exprs: exprs}
+}
+
+// Load parses all .pp files in the given directory and its subdirectories using a parser created with the
+// given options. An error is returned if a file cannot be read or parsed.
+func Load(dir string, options ...parser.Option) (*Sources, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, `.pp`) {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser.CreateParser(options...)
+	exprs := make(map[string]parser.Expression, len(files))
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		expr, err := p.Parse(file, string(content), false)
+		if err != nil {
+			return nil, err
+		}
+		exprs[file] = expr
+	}
+	return New(exprs), nil
+}
+
+// Files returns the paths of the parsed files in lexical order
+func (s *Sources) Files() []string {
+	return s.files
+}
+
+// Expression returns the parsed contents of the given file or nil if the file is not part of the sources
+func (s *Sources) Expression(file string) parser.Expression {
+	return s.exprs[file]
+}
@@ -0,0 +1,65 @@
+package module
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestLoad(t *testing.T) {
+	dir := writeModule(t, map[string]string{
+		`plans/init.pp`:   `plan mymod() {}`,
+		`plans/deploy.pp`: `plan mymod::deploy() {}`,
+		`types/port.pp`:   `type Mymod::Port = Integer[1, 65535]`,
+		`README.md`:       `not parsed`})
+	defer os.RemoveAll(dir)
+
+	s, err := Load(dir, parser.TasksEnabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := s.Files()
+	if len(files) != 3 || files[0] != filepath.Join(dir, `plans`, `deploy.pp`) || files[2] != filepath.Join(dir, `types`, `port.pp`) {
+		t.Fatalf(`unexpected files %v`, files)
+	}
+	if _, ok := s.Expression(files[0]).(*parser.Program); !ok {
+		t.Errorf(`expected the parsed program of %s`, files[0])
+	}
+	if s.Expression(filepath.Join(dir, `README.md`)) != nil {
+		t.Errorf(`expected README.md to not be parsed`)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := writeModule(t, map[string]string{`a.pp`: `plan a() {}`})
+	defer os.RemoveAll(dir)
+
+	// Plans are only recognized with tasks enabled
+	if _, err := Load(dir); err == nil {
+		t.Errorf(`expected a parse error`)
+	}
+	if _, err := Load(filepath.Join(dir, `missing`)); err == nil {
+		t.Errorf(`expected an error for a missing directory`)
+	}
+}
+
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir(``, `module`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+			err = ioutil.WriteFile(path, []byte(content), 0644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
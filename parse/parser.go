@@ -5,15 +5,23 @@ package main
 import (
 	"bytes"
 	"flag"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/docs"
 	"github.com/lyraproj/puppet-parser/json"
 	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/plan"
 	"github.com/lyraproj/puppet-parser/pn"
+	"github.com/lyraproj/puppet-parser/report"
 	"github.com/lyraproj/puppet-parser/validator"
+	"github.com/lyraproj/puppet-parser/workflow"
 )
 
 // Program to parse and validate a .pp or .epp file
@@ -21,16 +29,79 @@ var validateOnly = flag.Bool("v", false, "validate only")
 var jsonOutput = flag.Bool("j", false, "json output")
 var strict = flag.String("s", `off`, "strict (off, warning, or error)")
 var tasks = flag.Bool("t", false, "tasks")
-var workflow = flag.Bool("w", false, "workflow")
+var workflows = flag.Bool("w", false, "workflow")
+var graph = flag.String("graph", ``, "with -w, write the data flow graphs of the workflows (dot, mermaid, or json) instead of the AST")
+var descriptor = flag.String("descriptor", ``, "with -w, write descriptors of the workflows (json or yaml) instead of the AST")
+var module = flag.String("module", ``, "with -w, resolve the steps called by the steps of the file in the .pp files of this directory. With -t, resolve the plans run by the plans of the file")
+var deferred = flag.Bool("deferred", false, "with -w, write the variables and function calls in the state of resources that are resolved when the resources are applied instead of the AST")
+var scaffold = flag.String("scaffold", ``, "with -w, write Go handler stubs (go) or test fixtures (pp) for the actions and workflows instead of the AST")
+var signatures = flag.Bool("signatures", false, "with -t, write the parameter signatures of the plans as JSON instead of the AST")
+var goPackage = flag.String("package", `handlers`, "the package of the Go handler stubs written with -scaffold go")
+var doc = flag.Bool("doc", false, "generate reference documentation instead of AST")
+var enableRules = flag.String("rules", ``, "comma separated list of rule packs or issue codes of rules to enable")
+var configFile = flag.String("config", ``, "configuration file (default is "+validator.ConfigFileNames[0]+" found in the directory of the file or a parent)")
+var baseline = flag.String("baseline", ``, "write or check a baseline file of known issues given as the first argument")
+var format = flag.String("format", ``, "output format (text, json, sarif, junit, or checkstyle). The sarif, junit, and checkstyle formats write only the issues")
+var color = flag.Bool("color", false, "use ANSI colors in the text output")
+var severities = severityFlag{}
+
+func init() {
+	flag.Var(severities, "severity", "severity of an issue given as CODE=level where level is ignore, deprecation, warning, or error (may be repeated)")
+}
+
+type severityFlag map[string]string
+
+func (f severityFlag) String() string {
+	codes := make([]string, 0, len(f))
+	for code, level := range f {
+		codes = append(codes, code+`=`+level)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, `,`)
+}
+
+func (f severityFlag) Set(value string) error {
+	parts := strings.SplitN(value, `=`, 2)
+	if len(parts) != 2 {
+		return fmt.Errorf(`expected CODE=level, got '%s'`, value)
+	}
+	f[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	return nil
+}
 
 func main() {
 	flag.Parse()
 
 	args := flag.Args()
+	baselineFile := ``
+	if *baseline != `` {
+		if len(args) != 2 || *baseline != `write` && *baseline != `check` {
+			usage()
+		}
+		baselineFile = args[0]
+		args = args[1:]
+	}
 	if len(args) != 1 {
-		pn.Fprintln(os.Stderr, "Usage: parse [options] <pp or epp file to parse>\nValid options are:")
-		flag.PrintDefaults()
-		os.Exit(1)
+		usage()
+	}
+	if *graph != `` && (!*workflows || *graph != `dot` && *graph != `mermaid` && *graph != `json`) {
+		usage()
+	}
+	if *module != `` && !*workflows && !*tasks {
+		usage()
+	}
+	if *descriptor != `` && (!*workflows || *graph != `` || *descriptor != `json` && *descriptor != `yaml`) {
+		usage()
+	}
+	if *deferred && (!*workflows || *graph != `` || *descriptor != ``) {
+		usage()
+	}
+	if *scaffold != `` && (!*workflows || *graph != `` || *descriptor != `` || *deferred || *scaffold != `go` && *scaffold != `pp`) {
+		usage()
+	}
+
+	if *signatures && (!*tasks || *workflows) {
+		usage()
 	}
 
 	fileName := args[0]
@@ -39,9 +110,9 @@ func main() {
 		panic(err)
 	}
 
-	var result map[string]interface{}
-	if *jsonOutput {
-		result = make(map[string]interface{}, 2)
+	reporter, ok := newReporter(*format, string(content))
+	if !ok {
+		usage()
 	}
 
 	strictness := validator.Strict(*strict)
@@ -53,72 +124,334 @@ func main() {
 	if *tasks {
 		parseOpts = append(parseOpts, parser.TasksEnabled)
 	}
-	if *workflow {
+	if *workflows {
 		parseOpts = append(parseOpts, parser.WorkflowEnabled)
 	}
 
+	var issues []issue.Reported
+	var output report.Output
 	expr, err := parser.CreateParser(parseOpts...).Parse(args[0], string(content), false)
-	if *jsonOutput {
-		if err != nil {
-			if i, ok := err.(issue.Reported); ok {
-				result[`issues`] = []interface{}{pn.ReportedToPN(i).ToData()}
+	if err != nil {
+		i, ok := err.(issue.Reported)
+		if !ok {
+			if *jsonOutput {
+				emitJson(map[string]interface{}{`error`: err.Error()})
 			} else {
-				result[`error`] = err.Error()
+				pn.Fprintln(os.Stderr, err.Error())
 			}
-			emitJson(result)
-			// Parse error is always SeverityError
 			os.Exit(1)
 		}
-
-		v := validator.ValidatePuppet(expr, strictness)
-		if len(v.Issues()) > 0 {
-			severity := issue.Severity(issue.SeverityIgnore)
-			issues := make([]interface{}, len(v.Issues()))
-			for idx, i := range v.Issues() {
-				if i.Severity() > severity {
-					severity = i.Severity()
-				}
-				issues[idx] = pn.ReportedToPN(i).ToData()
+		// Parse error is always SeverityError
+		issues = []issue.Reported{i}
+	} else {
+		issues = applyBaseline(baselineFile, fileName, validate(fileName, expr, strictness).Issues())
+		if *deferred {
+			// Written also when there are errors since it flags the references that can never be resolved
+			output = deferredOutput{workflow.DeferredReferences(expr)}
+		} else if report.MaxSeverity(issues) < issue.SeverityError {
+			if *graph != `` {
+				output = graphOutput{workflow.Graphs(expr), *graph}
+			} else if *scaffold != `` {
+				output = scaffoldOutput{workflow.ScaffoldSteps(expr), *scaffold}
+			} else if *descriptor != `` {
+				output = descriptorOutput{workflow.Descriptors(expr), *descriptor}
+			} else if *signatures {
+				output = signaturesOutput{plan.Signatures(expr)}
+			} else if *doc {
+				output = docsOutput{reference(expr)}
+			} else if !*validateOnly {
+				output = astOutput{expr}
 			}
-			result[`issues`] = issues
-			if severity == issue.SeverityError {
-				emitJson(result)
-				os.Exit(1)
+		}
+	}
+
+	if err = reporter.Report(fileName, issues, output); err != nil {
+		panic(err)
+	}
+	if report.MaxSeverity(issues) == issue.SeverityError {
+		os.Exit(1)
+	}
+}
+
+// newReporter returns the reporter for the given format. The -j option is the same as the json format.
+// The text format shows the issues with the lines of the given source that they apply to.
+func newReporter(format, source string) (report.Reporter, bool) {
+	if *jsonOutput && format == `` {
+		format = `json`
+	}
+	switch format {
+	case ``, `text`:
+		return report.NewDiagnosticReporter(os.Stderr, os.Stdout, source, *color), true
+	case `json`:
+		return report.NewJSONReporter(os.Stdout), true
+	case `sarif`:
+		return report.NewSarifReporter(os.Stdout), true
+	case `junit`:
+		return report.NewJUnitReporter(os.Stdout), true
+	case `checkstyle`:
+		return report.NewCheckstyleReporter(os.Stdout), true
+	}
+	return nil, false
+}
+
+type astOutput struct {
+	expr parser.Expression
+}
+
+func (o astOutput) Key() string {
+	return `ast`
+}
+
+func (o astOutput) WriteText(w io.Writer) error {
+	b := bytes.NewBufferString(``)
+	o.expr.ToPN().Format(b)
+	b.WriteByte('\n')
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (o astOutput) ToData() interface{} {
+	return o.expr.ToPN().ToData()
+}
+
+type docsOutput struct {
+	ref *docs.Reference
+}
+
+func (o docsOutput) Key() string {
+	return `docs`
+}
+
+func (o docsOutput) WriteText(w io.Writer) error {
+	return o.ref.WriteMarkdown(w)
+}
+
+func (o docsOutput) ToData() interface{} {
+	return o.ref
+}
+
+type graphOutput struct {
+	graphs []*workflow.Graph
+	format string
+}
+
+func (o graphOutput) Key() string {
+	return `graph`
+}
+
+func (o graphOutput) WriteText(w io.Writer) error {
+	if o.format == `json` {
+		json.ToJson(o.graphs, w)
+		return nil
+	}
+	for i, g := range o.graphs {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
 			}
 		}
+		var err error
+		if o.format == `dot` {
+			err = g.WriteDOT(w)
+		} else {
+			err = g.WriteMermaid(w)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o graphOutput) ToData() interface{} {
+	return o.graphs
+}
+
+type descriptorOutput struct {
+	descriptors []*workflow.Descriptor
+	format      string
+}
 
-		if !*validateOnly {
-			result[`ast`] = expr.ToPN().ToData()
+func (o descriptorOutput) Key() string {
+	return `descriptors`
+}
+
+func (o descriptorOutput) WriteText(w io.Writer) error {
+	if o.format == `yaml` {
+		return workflow.WriteDescriptorsYAML(o.descriptors, w)
+	}
+	return workflow.WriteDescriptorsJSON(o.descriptors, w)
+}
+
+func (o descriptorOutput) ToData() interface{} {
+	return o.descriptors
+}
+
+type deferredOutput struct {
+	refs []*workflow.DeferredReference
+}
+
+func (o deferredOutput) Key() string {
+	return `deferred`
+}
+
+// WriteText writes one line per reference with its location, the step that it belongs to, and a note when
+// it can never be resolved
+func (o deferredOutput) WriteText(w io.Writer) error {
+	for _, ref := range o.refs {
+		line := fmt.Sprintf("%s:%d:%d: %s: %s", ref.File, ref.Line, ref.Column, ref.Step, ref.String())
+		if ref.Unresolvable {
+			line += ` (unresolvable, not a parameter of the resource)`
+		}
+		if _, err := io.WriteString(w, line+"\n"); err != nil {
+			return err
 		}
-		emitJson(result)
-		return
 	}
+	return nil
+}
 
-	if err != nil {
-		pn.Fprintln(os.Stderr, err.Error())
-		// Parse error is always SeverityError
-		os.Exit(1)
+func (o deferredOutput) ToData() interface{} {
+	return o.refs
+}
+
+type scaffoldOutput struct {
+	steps  []*parser.StepExpression
+	format string
+}
+
+func (o scaffoldOutput) Key() string {
+	return `scaffold`
+}
+
+func (o scaffoldOutput) WriteText(w io.Writer) error {
+	if o.format == `go` {
+		return workflow.WriteGoStubs(o.steps, *goPackage, w)
 	}
+	return workflow.WriteFixtures(o.steps, w)
+}
 
-	v := validator.ValidatePuppet(expr, strictness)
-	if len(v.Issues()) > 0 {
-		severity := issue.Severity(issue.SeverityIgnore)
-		for _, i := range v.Issues() {
-			pn.Fprintln(os.Stderr, i.String())
-			if i.Severity() > severity {
-				severity = i.Severity()
-			}
+func (o scaffoldOutput) ToData() interface{} {
+	b := bytes.NewBufferString(``)
+	if err := o.WriteText(b); err != nil {
+		panic(err)
+	}
+	return b.String()
+}
+
+type signaturesOutput struct {
+	signatures []*plan.Signature
+}
+
+func (o signaturesOutput) Key() string {
+	return `signatures`
+}
+
+func (o signaturesOutput) WriteText(w io.Writer) error {
+	return plan.WriteSignaturesJSON(o.signatures, w)
+}
+
+func (o signaturesOutput) ToData() interface{} {
+	return o.signatures
+}
+
+func usage() {
+	pn.Fprintln(os.Stderr, "Usage: parse [options] <pp or epp file to parse>\n       parse -baseline write|check [options] <baseline file> <pp or epp file to parse>\nValid options are:")
+	flag.PrintDefaults()
+	os.Exit(1)
+}
+
+// validate validates the given expression using the workflow or the tasks checker when -w or -t is given.
+// The strictness given with -s applies to all checkers.
+// The steps called by the steps of a workflow, and the plans run by the plans of a file with tasks, are
+// resolved in the module directory given with -module.
+// The checker is configured using the configuration file given with -config or, when no such file is
+// given, the configuration file found in the directory of the parsed file or one of its parents. The
+// -rules and -severity options are applied after the configuration file.
+func validate(fileName string, expr parser.Expression, strictness validator.Strictness) validator.Validator {
+	var v validator.Checker
+	switch {
+	case *workflows && *module != ``:
+		m, err := workflow.LoadModule(*module)
+		if err != nil {
+			pn.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
 		}
-		if severity == issue.SeverityError {
+		v = validator.NewModuleChecker(m)
+	case *workflows:
+		v = validator.NewWorkflowChecker()
+	case *tasks && *module != ``:
+		m, err := plan.LoadModule(*module)
+		if err != nil {
+			pn.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		v = validator.NewTasksModuleChecker(m)
+	case *tasks:
+		v = validator.NewTasksChecker()
+	default:
+		v = validator.NewChecker(strictness)
+	}
+	validator.SetStrictness(v, strictness)
+
+	configPath := *configFile
+	if configPath == `` {
+		configPath, _ = validator.FindConfig(filepath.Dir(fileName))
+	}
+	configs := make([]*validator.Config, 0, 2)
+	if configPath != `` {
+		config, err := validator.LoadConfig(configPath)
+		if err != nil {
+			pn.Fprintln(os.Stderr, err.Error())
 			os.Exit(1)
 		}
+		configs = append(configs, config)
 	}
 
-	if !*validateOnly {
-		b := bytes.NewBufferString(``)
-		expr.ToPN().Format(b)
-		pn.Println(b)
+	options := &validator.Config{Severities: severities}
+	if *enableRules != `` {
+		for _, name := range strings.Split(*enableRules, `,`) {
+			options.Enable = append(options.Enable, strings.TrimSpace(name))
+		}
+	}
+	configs = append(configs, options)
+
+	for _, config := range configs {
+		if err := config.Apply(v); err != nil {
+			// Issues caused by command line options have no location
+			pn.Fprintln(os.Stderr, strings.TrimSpace(err.Error()))
+			os.Exit(1)
+		}
+	}
+	validator.Validate(v, expr)
+	return v
+}
+
+// applyBaseline returns the issues that are not recorded in the given baseline file. In write mode, the
+// given issues are first recorded in the baseline file, replacing the entries previously recorded for
+// the parsed file. The issues are returned unchanged when no baseline file is given.
+func applyBaseline(baselineFile, fileName string, issues []issue.Reported) []issue.Reported {
+	if baselineFile == `` {
+		return issues
+	}
+	b, err := validator.LoadBaseline(baselineFile)
+	if err != nil {
+		pn.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if *baseline == `write` {
+		b.Record(fileName, issues)
+		if err = b.Save(baselineFile); err != nil {
+			panic(err)
+		}
+	}
+	return b.Check(fileName, issues)
+}
+
+func reference(expr parser.Expression) *docs.Reference {
+	if program, ok := expr.(*parser.Program); ok {
+		return docs.Extract(program)
 	}
+	return &docs.Reference{}
 }
 
 func emitJson(value interface{}) {
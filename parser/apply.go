@@ -0,0 +1,33 @@
+package parser
+
+import "github.com/lyraproj/puppet-parser/pn"
+
+// An ApplyExpression is an apply block of a plan, e.g. `apply($targets, '_catch_errors' => true) { ... }`.
+// The body contains catalog code that is compiled and applied on the targets given by the first argument.
+type ApplyExpression struct {
+	Positioned
+	arguments []Expression
+	body      Expression
+}
+
+// Arguments returns the targets and the optional options hash of the apply block
+func (e *ApplyExpression) Arguments() []Expression {
+	return e.arguments
+}
+
+// Body returns the catalog code of the apply block
+func (e *ApplyExpression) Body() Expression {
+	return e.body
+}
+
+func (e *ApplyExpression) AllContents(path []Expression, visitor PathVisitor) {
+	DeepVisit(e, path, visitor, e.arguments, e.body)
+}
+
+func (e *ApplyExpression) Contents(path []Expression, visitor PathVisitor) {
+	ShallowVisit(e, path, visitor, e.arguments, e.body)
+}
+
+func (e *ApplyExpression) ToPN() pn.PN {
+	return pn.Map([]pn.Entry{pnList(e.arguments).WithName(`args`), pnBlockAsEntry(`body`, e.body)}).AsCall(`apply`)
+}
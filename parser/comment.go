@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// A Comment is a '#' or '/* */' comment in the source. Comments are not part of the AST. They are
+// retained by the Program so that tools can find directives and documentation in them.
+type Comment struct {
+	Positioned
+}
+
+// Text returns the text of the comment without the comment delimiters and surrounding whitespace
+func (c *Comment) Text() string {
+	s := c.String()
+	if strings.HasPrefix(s, `#`) {
+		s = s[1:]
+	} else {
+		s = strings.TrimSuffix(strings.TrimPrefix(s, `/*`), `*/`)
+	}
+	return strings.TrimSpace(s)
+}
+
+// IsTrailing returns true if the comment is preceded by something other than whitespace on its first line
+func (c *Comment) IsTrailing() bool {
+	source := c.locator.String()
+	lineStart := strings.LastIndexByte(source[:c.offset], '\n') + 1
+	return strings.TrimSpace(source[lineStart:c.offset]) != ``
+}
+
+// Comments returns the comments of the program in the order they appear in the source
+func (e *Program) Comments() []*Comment {
+	return e.comments
+}
+
+// addComment records the comment that starts and ends at the given offsets. The lexer may scan the
+// same comment more than once, so comments are keyed by their start offset.
+func (ctx *context) addComment(start, end int) {
+	if ctx.comments == nil {
+		ctx.comments = make(map[int]*Comment)
+	}
+	if _, found := ctx.comments[start]; !found {
+		ctx.comments[start] = &Comment{Positioned{ctx.locator, start, end - start}}
+	}
+}
+
+func (ctx *context) sortedComments() []*Comment {
+	comments := make([]*Comment, 0, len(ctx.comments))
+	for _, c := range ctx.comments {
+		comments = append(comments, c)
+	}
+	sort.Slice(comments, func(i, j int) bool { return comments[i].offset < comments[j].offset })
+	return comments
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"sort"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -378,6 +379,7 @@ type (
 		Positioned
 		body        Expression
 		definitions []Definition
+		comments    []*Comment
 	}
 
 	qRefDefinition struct {
@@ -640,6 +642,39 @@ func (e *Positioned) ByteOffset() int {
 	return e.offset
 }
 
+// Return the byte offset of the end of the expression. Unlike ByteOffset() + ByteLength(), the end
+// doesn't include whitespace that trails the expression
+func (e *Positioned) EndOffset() int {
+	end := e.offset + len(strings.TrimRight(e.String(), " \t\r\n"))
+	if end == e.offset {
+		// Only whitespace
+		end += e.length
+	}
+	return end
+}
+
+// SourceOf returns the source text of the given expression up to its end offset, i.e. without the trailing
+// whitespace and tokens that are included in its byte length
+func SourceOf(e Expression) string {
+	s := e.String()
+	if sr, ok := e.(pn.SourceRange); ok {
+		if n := sr.EndOffset() - e.ByteOffset(); n >= 0 && n <= len(s) {
+			return s[:n]
+		}
+	}
+	return s
+}
+
+// Return the line of the end of the expression
+func (e *Positioned) EndLine() int {
+	return e.locator.LineForOffset(e.EndOffset())
+}
+
+// Return the position that follows the end of the expression on its end line
+func (e *Positioned) EndPos() int {
+	return e.locator.PosOnLine(e.EndOffset())
+}
+
 func (e *Positioned) Location() issue.Location {
 	return e
 }
@@ -813,6 +848,25 @@ func (e *binaryExpression) Rhs() Expression {
 	return e.rhs
 }
 
+// Return the end of the right hand side. Unlike ByteOffset() + ByteLength(), the end doesn't include the
+// token that follows the expression
+func (e *binaryExpression) EndOffset() int {
+	if sr, ok := e.rhs.(pn.SourceRange); ok {
+		return sr.EndOffset()
+	}
+	return e.Positioned.EndOffset()
+}
+
+// Return the line of the end of the right hand side
+func (e *binaryExpression) EndLine() int {
+	return e.locator.LineForOffset(e.EndOffset())
+}
+
+// Return the position that follows the end of the right hand side on its end line
+func (e *binaryExpression) EndPos() int {
+	return e.locator.PosOnLine(e.EndOffset())
+}
+
 func (e *BlockExpression) Statements() []Expression {
 	return e.statements
 }
@@ -1550,6 +1604,31 @@ func (e *Parameter) ToPN() pn.PN {
 	return pn.Map(entries).AsCall(`param`)
 }
 
+// Return the end of the default value, or of the variable when there is no default value. Unlike
+// ByteOffset() + ByteLength(), the end doesn't include the token that follows the parameter
+func (e *Parameter) EndOffset() int {
+	if e.value != nil {
+		if sr, ok := e.value.(pn.SourceRange); ok {
+			return sr.EndOffset()
+		}
+		return e.Positioned.EndOffset()
+	}
+	if ix := strings.LastIndex(e.String(), `$`+e.name); ix >= 0 {
+		return e.offset + ix + 1 + len(e.name)
+	}
+	return e.Positioned.EndOffset()
+}
+
+// Return the line of the end of the parameter
+func (e *Parameter) EndLine() int {
+	return e.locator.LineForOffset(e.EndOffset())
+}
+
+// Return the position that follows the end of the parameter on its end line
+func (e *Parameter) EndPos() int {
+	return e.locator.PosOnLine(e.EndOffset())
+}
+
 func (e *Parameter) Type() Expression {
 	return e.typeExpr
 }
@@ -2055,6 +2134,28 @@ func (e *VariableExpression) Contents(path []Expression, visitor PathVisitor) {
 
 func (e *VariableExpression) ToPN() pn.PN { return pn.Call(`var`, pn.Literal(e.NameOrIndex())) }
 
+// Return the end of the name of the variable, or of the closing brace of an interpolated `${name}`. Unlike
+// ByteOffset() + ByteLength(), the end doesn't include the token that follows the variable
+func (e *VariableExpression) EndOffset() int {
+	if strings.HasPrefix(e.String(), `${`) {
+		return e.Positioned.EndOffset()
+	}
+	if ix, ok := e.Index(); ok {
+		return e.expr.ByteOffset() + len(strconv.FormatInt(ix, 10))
+	}
+	return e.expr.ByteOffset() + e.expr.ByteLength()
+}
+
+// Return the line of the end of the variable
+func (e *VariableExpression) EndLine() int {
+	return e.locator.LineForOffset(e.EndOffset())
+}
+
+// Return the position that follows the end of the variable on its end line
+func (e *VariableExpression) EndPos() int {
+	return e.locator.PosOnLine(e.EndOffset())
+}
+
 func (e *VariableExpression) ToUnaryExpression() UnaryExpression {
 	return e
 }
@@ -7,6 +7,7 @@ type ExpressionFactory interface {
 	Step(name string, style StepStyle, properties, definition Expression, locator *Locator, offset int, length int) Expression
 	And(lhs Expression, rhs Expression, locator *Locator, offset int, length int) Expression
 	Application(name string, params []Expression, body Expression, locator *Locator, offset int, length int) Expression
+	Apply(args []Expression, body Expression, locator *Locator, offset int, length int) Expression
 	Array(expressions []Expression, locator *Locator, offset int, length int) Expression
 	Arithmetic(op string, lhs Expression, rhs Expression, locator *Locator, offset int, length int) Expression
 	Assignment(op string, lhs Expression, rhs Expression, locator *Locator, offset int, length int) Expression
@@ -96,6 +97,10 @@ func (f *defaultExpressionFactory) Application(name string, params []Expression,
 	return &Application{namedDefinition{Positioned{locator, offset, length}, name, params, body}}
 }
 
+func (f *defaultExpressionFactory) Apply(args []Expression, body Expression, locator *Locator, offset int, length int) Expression {
+	return &ApplyExpression{Positioned{locator, offset, length}, args, body}
+}
+
 func (f *defaultExpressionFactory) Arithmetic(op string, lhs Expression, rhs Expression, locator *Locator, offset int, length int) Expression {
 	return &ArithmeticExpression{binaryExpression{Positioned{locator, offset, length}, lhs, rhs}, op}
 }
@@ -249,7 +254,7 @@ func (f *defaultExpressionFactory) Plan(name string, parameters []Expression, bo
 }
 
 func (f *defaultExpressionFactory) Program(body Expression, definitions []Definition, locator *Locator, offset int, length int) Expression {
-	return &Program{Positioned{locator, offset, length}, body, definitions}
+	return &Program{Positioned{locator, offset, length}, body, definitions, nil}
 }
 
 func (f *defaultExpressionFactory) QualifiedName(name string, locator *Locator, offset int, length int) Expression {
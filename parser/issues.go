@@ -56,6 +56,9 @@ const (
 	parseQuotedNotValidName           = `PARSE_QUOTED_NOT_VALID_NAME`
 )
 
+// Formats the 'suggestion' argument of issues that end with a suggestion
+var suggestionFormat = issue.HF{`suggestion`: DidYouMean}
+
 func init() {
 	issue.Hard(lexDoubleColonNotFollowedByName, `:: not followed by name segment`)
 	issue.Hard(lexDigitExpected, `digit expected`)
@@ -76,7 +79,7 @@ func init() {
 	issue.Hard(lexMalformedUnicodeEscape, `malformed unicode escape sequence`)
 	issue.Hard(lexOctaldigitExpected, `octal digit expected`)
 	issue.Hard(lexUnbalancedEppComment, `unbalanced epp comment`)
-	issue.Hard(lexUnexpectedToken, `unexpected token '%{token}'`)
+	issue.Hard2(lexUnexpectedToken, `unexpected token '%{token}'%{suggestion}`, suggestionFormat)
 	issue.Hard(lexUnterminatedComment, `unterminated /* */ comment`)
 	issue.Hard(lexUnterminatedString, `unterminated %{string_type} quoted string`)
 
@@ -84,8 +87,8 @@ func init() {
 	issue.Hard(parseElsifInUnless, `elsif not supported in unless expression`)
 	issue.Hard(parseExpectedStepName, `expected %{step} name`)
 	issue.Hard(parseExpectedStepOperation, `expected one of 'delete', 'read', or 'upsert'. Got '%{operation}'`)
-	issue.Hard(parseExpectedIteratorStyle, `expected one of 'each', 'range', or 'times'. Got '%{style}`)
-	issue.Hard(parseExpectedStepStyle, `expected one of 'action', 'resource', or 'workflow'`)
+	issue.Hard2(parseExpectedIteratorStyle, `expected one of 'each', 'range', or 'times'. Got '%{style}'%{suggestion}`, suggestionFormat)
+	issue.Hard2(parseExpectedStepStyle, `expected one of 'action', 'resource', or 'workflow'%{suggestion}`, suggestionFormat)
 	issue.Hard(parseExpectedAttributeName, `expected attribute name`)
 	issue.Hard(parseExpectedClassName, `expected name of class`)
 	issue.Hard(parseExpectedFarrowAfterKey, `expected '=>' to follow hash key`)
@@ -93,9 +96,9 @@ func init() {
 	issue.Hard(parseExpectedNameOrNumberAfterDot, `expected name or number to follow '.'`)
 	issue.Hard(parseExpectedNameAfterFunction, `expected a name to follow keyword 'function'`)
 	issue.Hard(parseExpectedNameAfterPlan, `expected a name to follow keyword 'plan'`)
-	issue.Hard(parseExpectedOneOfTokens, `expected one of %{expected}, got '%{actual}'`)
+	issue.Hard2(parseExpectedOneOfTokens, `expected one of %{expected}, got '%{actual}'%{suggestion}`, suggestionFormat)
 	issue.Hard(parseExpectedTitle, `resource title expected`)
-	issue.Hard(parseExpectedToken, `expected token '%{expected}', got '%{actual}'`)
+	issue.Hard2(parseExpectedToken, `expected token '%{expected}', got '%{actual}'%{suggestion}`, suggestionFormat)
 	issue.Hard(parseExpectedTypeName, `expected type name`)
 	issue.Hard(parseExpectedTypeNameAfterType, `expected type name to follow 'type'`)
 	issue.Hard(parseExpectedVariable, `expected variable declaration`)
@@ -105,6 +108,7 @@ func init() {
 	issue.Hard(parseInvalidAttribute, `invalid attribute operation`)
 	issue.Hard(parseInvalidResource, `invalid resource expression`)
 	issue.Hard(parseInheritsMustBeTypeName, `expected type name to follow 'inherits'`)
-	issue.Hard(parseResourceWithoutTitle, `This expression is invalid. Did you try declaring a '%{name}' resource without a title?`)
+	issue.Hard2(parseResourceWithoutTitle, `This expression is invalid. Did you try declaring a '%{name}' resource without a title?%{suggestion}`,
+		issue.HF{`suggestion`: orDidYouMean})
 	issue.Hard(parseQuotedNotValidName, `a quoted string is not valid as a name at this location`)
 }
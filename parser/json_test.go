@@ -41,7 +41,7 @@ func toJSON(e Expression) string {
 func expectJSON(t *testing.T, source string, expected string) {
 	expr, err := CreateParser().Parse(``, source, false)
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Error(err.Error())
 	} else {
 		actual := toJSON(expr)
 		if expected != actual {
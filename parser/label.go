@@ -10,6 +10,7 @@ func (e *Positioned) Label() string { return "Positioned" }
 // Concrete
 func (e *StepExpression) Label() string              { return "Step" }
 func (e *AccessExpression) Label() string            { return "'[]' expression" }
+func (e *ApplyExpression) Label() string             { return "Apply Block" }
 func (e *AndExpression) Label() string               { return "'and' expression" }
 func (e *ArithmeticExpression) Label() string        { return fmt.Sprintf("'%s' expression", e.operator) }
 func (e *Application) Label() string                 { return "Application" }
@@ -29,11 +29,11 @@ func (l *location) Pos() int {
 }
 
 func (ctx *context) parseIssue(issueCode issue.Code) issue.Reported {
-	return issue.NewReported(issueCode, issue.SeverityError, issue.NoArgs, &location{ctx.locator, ctx.Pos()})
+	return ctx.parseIssue2(issueCode, issue.NoArgs)
 }
 
 func (ctx *context) parseIssue2(issueCode issue.Code, args issue.H) issue.Reported {
-	return issue.NewReported(issueCode, issue.SeverityError, args, &location{ctx.locator, ctx.Pos()})
+	return issue.NewReported(issueCode, issue.SeverityError, ctx.suggest(issueCode, args), &location{ctx.locator, ctx.Pos()})
 }
 
 const (
@@ -322,6 +322,9 @@ type context struct {
 	factory               ExpressionFactory
 	nameStack             []string
 	definitions           []Definition
+	comments              map[int]*Comment
+	lastStatementEnd      int
+	misspelledKeyword     string
 }
 
 func (ctx *context) setToken(token int) {
@@ -755,11 +758,17 @@ func (ctx *context) skipWhite(breakOnNewLine bool) (c rune, start int) {
 				ctx.SetPos(commentStartPos)
 				panic(ctx.parseIssue(lexUnterminatedComment))
 			}
+			if commentStart == '#' {
+				ctx.addComment(commentStartPos, start)
+			}
 			return
 		case '\n':
 			if commentStart == '*' {
 				continue
 			}
+			if commentStart == '#' {
+				ctx.addComment(commentStartPos, start)
+			}
 			if breakOnNewLine {
 				ctx.SetPos(start)
 				return
@@ -800,6 +809,7 @@ func (ctx *context) skipWhite(breakOnNewLine bool) (c rune, start int) {
 				if tc == '/' {
 					ctx.Advance(sz)
 					commentStart = 0
+					ctx.addComment(commentStartPos, ctx.Pos())
 				}
 				continue
 			}
@@ -1020,12 +1030,12 @@ func (ctx *context) consumeDelimitedString(delimiter rune, delimiterStart int, i
 
 			case delimiter:
 				buf.WriteRune(delimiter)
-				ec, _ = ctx.Next()
+				ec, start = ctx.Next()
 				continue
 
 			default:
 				handler(buf, ctx, ec)
-				ec, _ = ctx.Next()
+				ec, start = ctx.Next()
 				continue
 			}
 
@@ -1040,7 +1050,7 @@ func (ctx *context) consumeDelimitedString(delimiter rune, delimiterStart int, i
 			fallthrough
 		default:
 			buf.WriteRune(ec)
-			ec, _ = ctx.Next()
+			ec, start = ctx.Next()
 		}
 	}
 }
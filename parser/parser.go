@@ -163,11 +163,15 @@ func (ctx *context) Parse(filename string, source string, singleExpression bool)
 	ctx.stringReader = stringReader{text: source}
 	ctx.locator = &Locator{string: source, file: filename}
 	ctx.definitions = make([]Definition, 0, 8)
+	ctx.comments = nil
 	ctx.nextLineStart = -1
 
 	expr, err = ctx.parseTopExpression(filename, source, singleExpression)
 	if err == nil && !singleExpression {
 		expr = ctx.factory.Program(expr, ctx.definitions, ctx.locator, 0, ctx.Pos())
+		if program, ok := expr.(*Program); ok {
+			program.comments = ctx.sortedComments()
+		}
 	}
 	return
 }
@@ -202,7 +206,7 @@ func (ctx *context) parseTopExpression(filename string, source string, singleExp
 			if _, ok := e.(*BlockExpression); !ok {
 				e = ctx.factory.Block([]Expression{e}, ctx.locator, 0, ctx.Pos())
 			}
-			return ctx.factory.EppExpression([]Expression{}, e, ctx.locator, 0, ctx.Pos())
+			return ctx.factory.EppExpression(nil, e, ctx.locator, 0, ctx.Pos())
 		}
 
 		if ctx.currentToken == tokenEnd {
@@ -233,7 +237,10 @@ func (ctx *context) parseTopExpression(filename string, source string, singleExp
 				expr = asEppLambda(ctx.factory.Block(ctx.transformCalls(expressions, 0), ctx.locator, 0, ctx.Pos()))
 				return
 			}
-			expressions = append(expressions, ctx.expression())
+			expressions = append(expressions, ctx.syntacticStatement())
+			if ctx.currentToken == tokenSemicolon {
+				ctx.nextToken()
+			}
 		}
 	}
 
@@ -362,6 +369,7 @@ func (ctx *context) expressions(endToken int, producerFunc func() Expression) (e
 
 func (ctx *context) syntacticStatement() (expr Expression) {
 	var args []Expression
+	ctx.startStatement()
 	expr = ctx.relationship()
 	for ctx.currentToken == tokenComma {
 		ctx.nextToken()
@@ -374,6 +382,7 @@ func (ctx *context) syntacticStatement() (expr Expression) {
 	if args != nil {
 		expr = &commaSeparatedList{LiteralList{Positioned{ctx.locator, expr.ByteOffset(), ctx.Pos() - expr.ByteOffset()}, args}}
 	}
+	ctx.endStatement(expr)
 	return
 }
 
@@ -423,6 +432,11 @@ func (ctx *context) relationship() (expr Expression) {
 	}
 }
 
+// endOf returns the offset of the end of the given expression
+func endOf(e Expression) int {
+	return e.ByteOffset() + e.ByteLength()
+}
+
 func (ctx *context) assignment() (expr Expression) {
 	expr = ctx.step()
 	for {
@@ -1267,6 +1281,14 @@ func (ctx *context) callFunctionExpression(functorExpr Expression) Expression {
 		end = ctx.Pos()
 		ctx.nextToken()
 	}
+	if qn, ok := functorExpr.(*QualifiedName); ok && qn.name == `apply` && ctx.tasks && ctx.currentToken == tokenLc && args != nil {
+		// Apply block of a plan
+		ctx.nextToken()
+		body := ctx.parse(tokenRc, false)
+		end = ctx.Pos()
+		ctx.nextToken()
+		return ctx.factory.Apply(args, body, ctx.locator, start, end-start)
+	}
 	var block Expression
 	if ctx.currentToken == tokenPipe {
 		block = ctx.lambda()
@@ -1286,7 +1308,11 @@ func (ctx *context) stepStyle() StepStyle {
 			return style
 		}
 	}
-	panic(ctx.parseIssue(parseExpectedStepStyle))
+	s := ``
+	if ctx.currentToken == tokenIdentifier {
+		s = Suggestion(ctx.tokenString(), stepStyles())
+	}
+	panic(ctx.parseIssue2(parseExpectedStepStyle, issue.H{`suggestion`: s}))
 }
 
 func (ctx *context) stepName(step StepStyle) string {
@@ -1312,15 +1338,21 @@ func (ctx *context) stepProperty() Expression {
 	vstart := ctx.Pos()
 	name := key.(*QualifiedName).name
 	var value Expression
-	switch name {
-	case `parameters`:
-		// TODO: Allow non condensed declaration using array of hashes where everything is
-		// spelled out (type and value expressed in hash)
+	switch {
+	case (name == `parameters` || name == `returns`) && (ctx.currentToken == tokenListstart || ctx.currentToken == tokenLb):
+		// Non condensed declaration using an array of hashes where everything is spelled out
+		value = ctx.hashEntry()
+		if list, ok := value.(*LiteralList); ok {
+			decls := make([]Expression, len(list.elements))
+			for i, e := range list.elements {
+				decls[i] = ctx.expandedDeclaration(name, e)
+			}
+			value = ctx.factory.Array(decls, ctx.locator, list.offset, list.length)
+		}
+	case name == `parameters`:
 		value = ctx.factory.Array(ctx.parameterList(), ctx.locator, vstart, ctx.Pos()-vstart)
 		ctx.nextToken()
-	case `returns`:
-		// TODO: Allow non condensed declaration using array of hashes where everything is
-		// spelled out (type and value expressed in hash)
+	case name == `returns`:
 		params := ctx.returnParameters()
 		value = ctx.factory.Array(params, ctx.locator, vstart, ctx.Pos()-vstart)
 		ctx.nextToken()
@@ -1331,6 +1363,84 @@ func (ctx *context) stepProperty() Expression {
 	return ctx.factory.KeyedEntry(key, value, ctx.locator, start, ctx.Pos()-start)
 }
 
+// expandedDeclaration returns a Parameter for a hash that declares a parameter or a return value with its
+// 'name', 'type', and 'value' (parameters) or 'alias' (returns) spelled out. The Parameter is equal to the
+// one produced by the condensed declaration. Other expressions are returned unchanged so that the
+// validator can report them.
+func (ctx *context) expandedDeclaration(property string, e Expression) Expression {
+	hash, ok := e.(*LiteralHash)
+	if !ok {
+		return e
+	}
+	valueKey := `value`
+	if property == `returns` {
+		valueKey = `alias`
+	}
+	var name string
+	var typeExpr, value Expression
+	for _, entry := range hash.entries {
+		ke, ok := entry.(*KeyedEntry)
+		if !ok {
+			return e
+		}
+		key, _ := nameValue(ke.key)
+		switch key {
+		case `name`:
+			if name, ok = nameValue(ke.value); !ok {
+				return e
+			}
+		case `type`:
+			typeExpr = ke.value
+		case valueKey:
+			if value, ok = ctx.declaredValue(property, ke.value); !ok {
+				return e
+			}
+		default:
+			return e
+		}
+	}
+	if name == `` {
+		return e
+	}
+	return ctx.factory.Parameter(name, value, typeExpr, false, ctx.locator, hash.offset, hash.length)
+}
+
+// declaredValue returns the value of a parameter or, for a return value, the alias. An alias must be the
+// name of an attribute or a list of such names. The names are converted to strings.
+func (ctx *context) declaredValue(property string, e Expression) (Expression, bool) {
+	if property != `returns` {
+		return e, true
+	}
+	if list, ok := e.(*LiteralList); ok {
+		names := make([]Expression, len(list.elements))
+		for i, element := range list.elements {
+			n, ok := nameValue(element)
+			if !ok {
+				return nil, false
+			}
+			names[i] = ctx.factory.String(n, ctx.locator, element.ByteOffset(), element.ByteLength())
+		}
+		return ctx.factory.Array(names, ctx.locator, list.offset, list.length), true
+	}
+	if n, ok := nameValue(e); ok {
+		return ctx.factory.String(n, ctx.locator, e.ByteOffset(), e.ByteLength()), true
+	}
+	return nil, false
+}
+
+// nameValue returns the string of a literal string or a bare word
+func nameValue(e Expression) (string, bool) {
+	switch e := e.(type) {
+	case *LiteralString:
+		return e.StringValue(), true
+	case *QualifiedName:
+		return e.name, true
+	case *ReservedWord:
+		return e.Name(), true
+	}
+	return ``, false
+}
+
 func (ctx *context) stateHash(start int) []Expression {
 	entries := ctx.expressions(tokenRc, ctx.stateAttribute)
 
@@ -1417,7 +1527,6 @@ func (ctx *context) activities() []Expression {
 	for ctx.currentToken != tokenRc {
 		activities = append(activities, ctx.stepExpression())
 	}
-	ctx.nextToken()
 	return activities
 }
 
@@ -1431,6 +1540,7 @@ func (ctx *context) stepDeclaration(start int, style StepStyle, name string, atT
 	ctx.nextToken()
 	propEntries := ctx.expressions(tokenRc, ctx.stepProperty)
 	hEnd := ctx.Pos()
+	end := hEnd
 	ctx.nextToken()
 
 	f := ctx.factory
@@ -1445,7 +1555,8 @@ func (ctx *context) stepDeclaration(start int, style StepStyle, name string, atT
 		ctx.assertToken(tokenIdentifier)
 	}
 
-	if ctx.currentToken == tokenIdentifier {
+	// A step without a definition may be followed by the next step of the workflow
+	if _, nextStep := workflowStyles[ctx.tokenString()]; ctx.currentToken == tokenIdentifier && !nextStep {
 		switch ctx.tokenString() {
 		case `times`, `range`, `each`:
 			iterFunc := ctx.tokenString()
@@ -1456,8 +1567,9 @@ func (ctx *context) stepDeclaration(start int, style StepStyle, name string, atT
 			iterParams := ctx.parameterList()
 			ctx.nextToken()
 			vs := ctx.Pos()
-			pl := ps - vs
+			pl := vs - ps
 			iterVars := ctx.lambdaParameterList()
+			end = ctx.Pos()
 			ctx.nextToken()
 			vl := ctx.Pos() - vs
 			fn := ctx.Pos() - fs
@@ -1478,7 +1590,8 @@ func (ctx *context) stepDeclaration(start int, style StepStyle, name string, atT
 				}, l, fs, fn)
 			propEntries = append(propEntries, f.KeyedEntry(f.QualifiedName(`iteration`, l, fs, 0), iter, l, fs, fn))
 		default:
-			panic(ctx.parseIssue2(parseExpectedIteratorStyle, issue.H{`style`: ctx.tokenString()}))
+			style := ctx.tokenString()
+			panic(ctx.parseIssue2(parseExpectedIteratorStyle, issue.H{`style`: style, `suggestion`: Suggestion(style, iteratorStyles)}))
 		}
 	}
 	var properties Expression
@@ -1494,9 +1607,11 @@ func (ctx *context) stepDeclaration(start int, style StepStyle, name string, atT
 			hstart := ctx.tokenStartPos
 			ctx.nextToken()
 			activities := ctx.activities()
+			end = ctx.Pos()
 			if len(activities) > 0 {
-				block = ctx.factory.Block(activities, ctx.locator, hstart, ctx.Pos()-hstart)
+				block = ctx.factory.Block(activities, ctx.locator, hstart, end-hstart)
 			}
+			ctx.nextToken()
 		}
 
 		// Pop name stack
@@ -1509,15 +1624,17 @@ func (ctx *context) stepDeclaration(start int, style StepStyle, name string, atT
 			if len(entries) > 0 {
 				block = ctx.factory.Hash(entries, ctx.locator, start, ctx.Pos()-start).(*LiteralHash)
 			}
+			end = ctx.Pos()
 			ctx.nextToken()
 		}
 	default: // StepStyleAction or StepStyleStateHandler
 		ctx.assertToken(tokenLc)
 		ctx.nextToken()
 		block = ctx.parse(tokenRc, false)
+		end = ctx.Pos()
 		ctx.nextToken()
 	}
-	step := f.Step(ctx.qualifiedName(name), style, properties, block, l, start, ctx.Pos()-start)
+	step := f.Step(ctx.qualifiedName(name), style, properties, block, l, start, end-start)
 	if atTop {
 		ctx.addDefinition(step)
 	}
@@ -1831,7 +1948,7 @@ func (ctx *context) returnParameter() Expression {
 		case tokenLp, tokenWslp:
 			ps := ctx.tokenStartPos
 			ctx.nextToken()
-			defaultExpression = ctx.factory.Array(ctx.expressions(tokenRp, ctx.attributeAlias), ctx.locator, ps, ps-ctx.Pos())
+			defaultExpression = ctx.factory.Array(ctx.expressions(tokenRp, ctx.attributeAlias), ctx.locator, ps, ctx.Pos()-ps)
 			ctx.nextToken()
 		default:
 			defaultExpression = ctx.attributeAlias()
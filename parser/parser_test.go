@@ -742,6 +742,25 @@ func TestPlanDefinition(t *testing.T) {
 		`(= (var "a") (qn "plan"))`)
 }
 
+func TestApplyBlock(t *testing.T) {
+	expectDump(t,
+		issue.Unindent(`
+      plan foo(TargetSpec $targets) {
+        $r = apply($targets, '_catch_errors' => true) {
+          package { 'nginx': ensure => installed }
+        }
+        apply($targets) { }
+      }`),
+		`(plan {:name "foo" :params {:targets {:type (qr "TargetSpec")}} :body [`+
+			`(= (var "r") (apply {:args [(var "targets") (hash (=> "_catch_errors" true))] :body [`+
+			`(resource {:type (qn "package") :bodies [{:title "nginx" :ops [(=> "ensure" (qn "installed"))]}]})]})) `+
+			`(apply {:args [(var "targets")] :body []})]})`,
+		TasksEnabled)
+
+	// Not an apply block unless tasks are enabled
+	expectError(t, `apply($targets) { notify { x: } }`, `invalid resource expression (line: 1, column: 1)`)
+}
+
 func TestWorkflowDefinition(t *testing.T) {
 	expectDump(t, `workflow foo { }`,
 		`(step {:name "foo" :style "workflow"})`, WorkflowEnabled)
@@ -830,6 +849,60 @@ func TestWorkflowDefinition(t *testing.T) {
 		WorkflowEnabled)
 }
 
+func TestStepWithoutDefinition(t *testing.T) {
+	expectDump(t,
+		issue.Unindent(`
+      workflow foo {} {
+        workflow bar {
+          call => baz::bar
+        }
+        action qux {} {}
+      }`),
+		`(step {:name "foo" :style "workflow" :definition (block `+
+			`(step {:name "foo::bar" :style "workflow" :properties (hash (=> (qn "call") (qn "baz::bar")))}) `+
+			`(step {:name "foo::qux" :style "action" :definition (block)}))})`,
+		WorkflowEnabled)
+}
+
+func TestExpandedStepDeclarations(t *testing.T) {
+	expected := `(step {:name "foo" :style "workflow" :properties (hash ` +
+		`(=> (qn "parameters") (array (param {:name "a" :type (qr "String")}) (param {:name "b" :value "x"}))) ` +
+		`(=> (qn "returns") (array (param {:name "c" :type (qr "Integer")}) (param {:name "d" :value "attr"}) (param {:name "e" :value (array "x" "y")}))))})`
+
+	expectDump(t,
+		issue.Unindent(`
+      workflow foo {
+        parameters => (String $a, $b = 'x'),
+        returns => (Integer $c, $d = attr, $e = (x, y))
+      }`),
+		expected, WorkflowEnabled)
+
+	expectDump(t,
+		issue.Unindent(`
+      workflow foo {
+        parameters => [
+          { name => a, type => String },
+          { 'name' => 'b', value => 'x' }
+        ],
+        returns => [
+          { name => c, type => Integer },
+          { name => d, alias => attr },
+          { name => e, alias => [x, 'y'] }
+        ]
+      }`),
+		expected, WorkflowEnabled)
+
+	// Invalid declarations are left for the validator
+	expectDump(t,
+		issue.Unindent(`
+      workflow foo {
+        parameters => [{ name => a, typ => String }, $b]
+      }`),
+		`(step {:name "foo" :style "workflow" :properties (hash `+
+			`(=> (qn "parameters") (array (hash (=> (qn "name") (qn "a")) (=> (qn "typ") (qr "String"))) (var "b"))))})`,
+		WorkflowEnabled)
+}
+
 func TestNodeDefinition(t *testing.T) {
 	expectDump(t,
 		issue.Unindent(`
@@ -1956,6 +2029,11 @@ func TestEPP(t *testing.T) {
       <%-||-%> some <%- $x = 3 %> text`),
 		`(lambda {:body [(epp (render-s "some") (= (var "x") 3) (render-s " text"))]})`)
 
+	expectDumpEPP(t,
+		issue.Unindent(`
+      some <% $x = 3; $y = $x %> text`),
+		`(lambda {:body [(epp (render-s "some ") (= (var "x") 3) (= (var "y") (var "x")) (render-s " text"))]})`)
+
 	expectErrorEPP(t,
 		issue.Unindent(`
       <%-||-%> some <%- $x = 3 -% $y %> text`),
@@ -2058,6 +2136,193 @@ func TestEPP(t *testing.T) {
 		`Ambiguous EPP parameter expression. Probably missing '<%-' before parameters to remove leading whitespace (line: 2, column: 5)`)
 }
 
+func TestEPPParametersSpecified(t *testing.T) {
+	for source, expected := range map[string]bool{
+		`text`:                     false,
+		`<%= $x %>`:                false,
+		`<%||%><%= $x %>`:          true,
+		`<%|String $x|%><%= $x %>`: true,
+	} {
+		if expr := parseExpression(t, source, EppMode); expr != nil {
+			actual := expr.(*LambdaExpression).Body().(*EppExpression).ParametersSpecified()
+			if expected != actual {
+				t.Errorf(`expected ParametersSpecified() of '%s' to be %t`, source, expected)
+			}
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	source := "# first\n$x = 1 # trailing\n/* block\n comment */\n$y = @(END) # heredoc\n  text\n  END\n#last"
+	expr, err := CreateParser().Parse(``, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		text     string
+		line     int
+		trailing bool
+	}{
+		{`first`, 1, false},
+		{`trailing`, 2, true},
+		{"block\n comment", 3, false},
+		{`heredoc`, 5, true},
+		{`last`, 8, false},
+	}
+	comments := expr.(*Program).Comments()
+	if len(comments) != len(expected) {
+		t.Fatalf(`expected %d comments, got %d`, len(expected), len(comments))
+	}
+	for i, c := range comments {
+		if c.Text() != expected[i].text || c.Line() != expected[i].line || c.IsTrailing() != expected[i].trailing {
+			t.Errorf(`expected comment %q on line %d, trailing %t, got %q on line %d, trailing %t`,
+				expected[i].text, expected[i].line, expected[i].trailing, c.Text(), c.Line(), c.IsTrailing())
+		}
+	}
+}
+
+func TestBinaryExpressionRange(t *testing.T) {
+	expr, err := CreateParser().Parse(``, "$x = 1 +\n  2 * 3\nnotice($x)\n", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment := expr.(*Program).Body().(*BlockExpression).Statements()[0].(*AssignmentExpression)
+	if s := SourceOf(assignment); s != "$x = 1 +\n  2 * 3" {
+		t.Errorf(`unexpected source %q`, s)
+	}
+	if end := assignment.EndOffset(); end != 16 {
+		t.Errorf(`expected end offset 16, got %d`, end)
+	}
+	if l, p := assignment.EndLine(), assignment.EndPos(); l != 2 || p != 8 {
+		t.Errorf(`expected end at line 2, position 8, got line %d, position %d`, l, p)
+	}
+}
+
+func TestVariableAndParameterRange(t *testing.T) {
+	expr, err := CreateParser().Parse(``, "function foo(Integer $x, $y = 2 ) { [$x, $y] }", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := expr.(*Program).Body().(*BlockExpression).Statements()[0].(*FunctionDefinition)
+	for i, expected := range []string{`Integer $x`, `$y = 2`} {
+		if s := SourceOf(fn.Parameters()[i]); s != expected {
+			t.Errorf(`expected parameter source '%s', got '%s'`, expected, s)
+		}
+	}
+	elements := fn.Body().(*BlockExpression).Statements()[0].(*LiteralList).Elements()
+	if s := SourceOf(elements[0]); s != `$x` {
+		t.Errorf(`expected variable source '$x', got '%s'`, s)
+	}
+	if s := elements[0].String(); s != `$x,` {
+		t.Errorf(`expected the range of the variable to be unchanged, got '%s'`, s)
+	}
+
+	expr, err = CreateParser().Parse(``, "notice($1, \"${x} \")", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := expr.(*Program).Body().(*BlockExpression).Statements()[0].(*CallNamedFunctionExpression).Arguments()
+	if s := SourceOf(args[0]); s != `$1` {
+		t.Errorf(`expected variable source '$1', got '%s'`, s)
+	}
+	if s := SourceOf(args[1].(*ConcatenatedString).Segments()[0].(*TextExpression).Expr()); s != `${x}` {
+		t.Errorf(`expected variable source '${x}', got '%s'`, s)
+	}
+}
+
+func TestInterpolationRange(t *testing.T) {
+	for source, expected := range map[string]string{
+		`"${x}"`:          `${x}`,
+		`"rm -rf ${dir}"`: `${dir}`,
+		`"a\"$x"`:         `$x`,
+		`"ab\n${x}"`:      `${x}`,
+	} {
+		expr, err := CreateParser().Parse(``, source, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		segments := expr.(*Program).Body().(*BlockExpression).Statements()[0].(*ConcatenatedString).Segments()
+		if s := segments[len(segments)-1].String(); s != expected {
+			t.Errorf(`expected interpolation source '%s' in %s, got '%s'`, expected, source, s)
+		}
+	}
+}
+
+func TestMisspelledKeywordSuggestion(t *testing.T) {
+	expectError(t,
+		`clas foo { }`,
+		`unexpected token '}'. Did you mean 'class'? (line: 1, column: 12)`)
+
+	expectError(t,
+		`clas foo { notice(1) }`,
+		`This expression is invalid. Did you try declaring a 'foo' resource without a title? Or did you mean 'class'? (line: 1, column: 6)`)
+
+	expectError(t,
+		`unles $x { }`,
+		`unexpected token '}'. Did you mean 'unless'? (line: 1, column: 12)`)
+
+	expectError(t,
+		`if $x { } elseif $y { }`,
+		`unexpected token '}'. Did you mean 'elsif'? (line: 1, column: 23)`)
+
+	expectError(t,
+		`class foo inherit bar { }`,
+		`expected token '{', got 'identifier'. Did you mean 'inherits'? (line: 1, column: 11)`)
+
+	expectError(t,
+		"clas\nnotice(1)\n$x = 1 +\n",
+		`unexpected token 'EOF' (line: 4, column: 1)`)
+
+	expectError(t,
+		`ntoice(1) }`,
+		`unexpected token '}' (line: 1, column: 11)`)
+}
+
+func TestMisspelledStyleSuggestion(t *testing.T) {
+	expectError(t,
+		issue.Unindent(`
+      workflow foo {} {
+        actoin bar {} { }
+      }`),
+		`expected one of 'action', 'resource', or 'workflow'. Did you mean 'action'? (line: 2, column: 9)`,
+		WorkflowEnabled)
+
+	expectError(t,
+		issue.Unindent(`
+      workflow foo {} {
+        resource bar {} tims($y) |$x| { }
+      }`),
+		`expected one of 'each', 'range', or 'times'. Got 'tims'. Did you mean 'times'? (line: 2, column: 23)`,
+		WorkflowEnabled)
+
+	expectError(t,
+		issue.Unindent(`
+      workflow foo {} {
+        resource bar {} loop($y) |$x| { }
+      }`),
+		`expected one of 'each', 'range', or 'times'. Got 'loop' (line: 2, column: 23)`,
+		WorkflowEnabled)
+}
+
+func TestSuggestion(t *testing.T) {
+	tests := []struct {
+		word     string
+		expected string
+	}{
+		{`clas`, `class`},
+		{`fucntion`, `function`},
+		{`defien`, `define`},
+		{`class`, ``},
+		{`cls`, ``},
+		{`notice`, ``},
+	}
+	for _, test := range tests {
+		if s := Suggestion(test.word, suggestedKeywords); s != test.expected {
+			t.Errorf(`expected suggestion '%s' for '%s', got '%s'`, test.expected, test.word, s)
+		}
+	}
+}
+
 func expectDumpEPP(t *testing.T, source string, expected string) {
 	expectDump(t, source, expected, EppMode)
 }
@@ -2078,7 +2343,7 @@ func expectDump(t *testing.T, source string, expected string, parserOptions ...O
 func expectBlock(t *testing.T, source string, expected string, parserOptions ...Option) {
 	expr, err := CreateParser(parserOptions...).Parse(``, source, false)
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Error(err.Error())
 	} else {
 		actual := dump(expr)
 		if expected != actual {
@@ -2131,7 +2396,7 @@ func expectHeredoc(t *testing.T, str string, args ...interface{}) {
 func parse(t *testing.T, str string, parserOptions ...Option) Expression {
 	expr, err := CreateParser(parserOptions...).Parse(``, str, false)
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Error(err.Error())
 		return nil
 	}
 	program, ok := expr.(*Program)
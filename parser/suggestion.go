@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+// Keywords that may be suggested when an identifier that is a misspelled keyword is found where the
+// keyword would have been valid.
+var suggestedKeywords = []string{
+	tokenMap[tokenApplication],
+	tokenMap[tokenCase],
+	tokenMap[tokenClass],
+	tokenMap[tokenConsumes],
+	tokenMap[tokenDefine],
+	tokenMap[tokenElse],
+	tokenMap[tokenElsif],
+	tokenMap[tokenFunction],
+	tokenMap[tokenIf],
+	tokenMap[tokenInherits],
+	tokenMap[tokenNode],
+	tokenMap[tokenPlan],
+	tokenMap[tokenProduces],
+	tokenMap[tokenSite],
+	tokenMap[tokenType],
+	tokenMap[tokenUnless],
+}
+
+var iteratorStyles = []string{`each`, `range`, `times`}
+
+// Issues that have a 'suggestion' argument
+var suggestingIssues = map[issue.Code]bool{
+	lexUnexpectedToken:         true,
+	parseExpectedIteratorStyle: true,
+	parseExpectedOneOfTokens:   true,
+	parseExpectedStepStyle:     true,
+	parseExpectedToken:         true,
+	parseResourceWithoutTitle:  true,
+}
+
+// DidYouMean formats the 'suggestion' argument as a sentence. It produces nothing when there's no suggestion.
+func DidYouMean(value interface{}) string {
+	if s, ok := value.(string); ok && s != `` {
+		return fmt.Sprintf(`. Did you mean '%s'?`, s)
+	}
+	return ``
+}
+
+// orDidYouMean formats the 'suggestion' argument of a message that already ends with a question.
+func orDidYouMean(value interface{}) string {
+	if s, ok := value.(string); ok && s != `` {
+		return fmt.Sprintf(` Or did you mean '%s'?`, s)
+	}
+	return ``
+}
+
+// Suggestion returns the candidate that is closest to the given word, or the empty string when no
+// candidate is close enough to be a likely replacement. A candidate is close enough when the number of
+// edits needed to turn the word into the candidate is at most two and at most a third of the length
+// of the word. An edit is an insertion, deletion, or substitution of one character, or a transposition
+// of two adjacent characters.
+func Suggestion(word string, candidates []string) string {
+	best := ``
+	bestDistance := 3
+	for _, c := range candidates {
+		if c == word {
+			return ``
+		}
+		d := editDistance(word, c)
+		if d < bestDistance && d*3 <= len(word) {
+			best = c
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// editDistance returns the optimal string alignment distance between the two strings
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// stepStyles returns the names of the step styles in alphabetical order
+func stepStyles() []string {
+	styles := make([]string, 0, len(workflowStyles))
+	for style := range workflowStyles {
+		styles = append(styles, style)
+	}
+	sort.Strings(styles)
+	return styles
+}
+
+// startStatement is called before a statement is parsed. A preceding statement that consists of a bare
+// name that is similar to a keyword is likely to be a misspelled keyword. Its suggestion is retained
+// until a statement starts on a new line.
+func (ctx *context) startStatement() {
+	start := ctx.tokenStartPos
+	if ctx.lastStatementEnd > start || strings.ContainsRune(ctx.text[ctx.lastStatementEnd:start], '\n') {
+		ctx.misspelledKeyword = ``
+	}
+}
+
+// endStatement is called with each statement that has been parsed.
+func (ctx *context) endStatement(expr Expression) {
+	ctx.lastStatementEnd = endOf(expr)
+	if qn, ok := expr.(*QualifiedName); ok && !statementCalls[qn.name] {
+		candidates := suggestedKeywords
+		if ctx.workflow {
+			candidates = append(stepStyles(), candidates...)
+		}
+		if s := Suggestion(qn.name, candidates); s != `` {
+			ctx.misspelledKeyword = s
+		}
+	}
+}
+
+// suggest returns the arguments of the given issue with the 'suggestion' argument added when the issue
+// has one. Unless given, the suggestion is the keyword similar to a preceding misspelled keyword or to
+// the current identifier.
+func (ctx *context) suggest(issueCode issue.Code, args issue.H) issue.H {
+	if !suggestingIssues[issueCode] {
+		return args
+	}
+	result := make(issue.H, len(args)+1)
+	for k, v := range args {
+		result[k] = v
+	}
+	if _, ok := result[`suggestion`]; !ok {
+		s := ctx.misspelledKeyword
+		if s == `` && ctx.currentToken == tokenIdentifier {
+			s = Suggestion(ctx.tokenString(), suggestedKeywords)
+		}
+		result[`suggestion`] = s
+	}
+	return result
+}
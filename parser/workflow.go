@@ -58,3 +58,91 @@ func (e *StepExpression) ToPN() pn.PN {
 	}
 	return pn.Map(entries).AsCall(`step`)
 }
+
+// Property returns the value of the named property of the step, or nil if the step has no such property
+func (e *StepExpression) Property(name string) Expression {
+	return hashValue(e.properties, name)
+}
+
+// Parameters returns the parameters declared by the 'parameters' property of the step
+func (e *StepExpression) Parameters() []Expression {
+	return listElements(e.Property(`parameters`))
+}
+
+// Returns returns the return values declared by the 'returns' property of the step
+func (e *StepExpression) Returns() []Expression {
+	return listElements(e.Property(`returns`))
+}
+
+// Call returns the qualified name of the step that the 'call' property of the step refers to, or the empty
+// string if the step has no such property or if its value is not a name
+func (e *StepExpression) Call() string {
+	switch call := e.Property(`call`).(type) {
+	case *QualifiedName:
+		return call.Name()
+	case *LiteralString:
+		return call.StringValue()
+	}
+	return ``
+}
+
+// IterationFunction returns the name of the function of the iteration of the step, i.e. 'each', 'range',
+// or 'times', or the empty string if the step is not iterated
+func (e *StepExpression) IterationFunction() string {
+	if qn, ok := hashValue(e.Property(`iteration`), `function`).(*QualifiedName); ok {
+		return qn.Name()
+	}
+	return ``
+}
+
+// IterationName returns the name under which the returns of the iterations of the step are exposed, or the
+// empty string if the step is not iterated
+func (e *StepExpression) IterationName() string {
+	if qn, ok := hashValue(e.Property(`iteration`), `name`).(*QualifiedName); ok {
+		return qn.Name()
+	}
+	return ``
+}
+
+// IterationParameters returns the parameters of the iteration of the step
+func (e *StepExpression) IterationParameters() []Expression {
+	return listElements(hashValue(e.Property(`iteration`), `params`))
+}
+
+// IterationVariables returns the variables of the iteration of the step
+func (e *StepExpression) IterationVariables() []Expression {
+	return listElements(hashValue(e.Property(`iteration`), `vars`))
+}
+
+// Steps returns the steps declared in the body of a workflow
+func (e *StepExpression) Steps() []*StepExpression {
+	steps := make([]*StepExpression, 0)
+	if block, ok := e.definition.(*BlockExpression); ok {
+		for _, s := range block.Statements() {
+			if step, ok := s.(*StepExpression); ok {
+				steps = append(steps, step)
+			}
+		}
+	}
+	return steps
+}
+
+func hashValue(e Expression, key string) Expression {
+	if hash, ok := e.(*LiteralHash); ok {
+		for _, entry := range hash.entries {
+			if ke, ok := entry.(*KeyedEntry); ok {
+				if qn, ok := ke.key.(*QualifiedName); ok && qn.name == key {
+					return ke.value
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func listElements(e Expression) []Expression {
+	if list, ok := e.(*LiteralList); ok {
+		return list.elements
+	}
+	return []Expression{}
+}
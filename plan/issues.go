@@ -0,0 +1,13 @@
+package plan
+
+import (
+	"github.com/lyraproj/issue/issue"
+)
+
+const (
+	PlanDuplicate = `PLAN_DUPLICATE`
+)
+
+func init() {
+	issue.Hard(PlanDuplicate, `The plan '%{name}' is also declared in %{file}`)
+}
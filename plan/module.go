@@ -0,0 +1,64 @@
+package plan
+
+import (
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/module"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// A Module is the set of plans defined in the .pp files of a directory and its subdirectories. A plan of one
+// file can run a plan of another file using the qualified name of that plan.
+type Module struct {
+	*module.Sources
+	plans map[string]*Signature
+}
+
+// LoadModule parses all .pp files in the given directory and its subdirectories with tasks enabled. An error
+// is returned if a file cannot be read or parsed, or if a plan is defined in more than one place. The type
+// aliases defined in any of the files are used when resolving the types of the parameters of the plans.
+func LoadModule(dir string) (*Module, error) {
+	sources, err := module.Load(dir, parser.TasksEnabled)
+	if err != nil {
+		return nil, err
+	}
+	return NewModule(sources)
+}
+
+// NewModule returns the module of the given sources. An error is returned if a plan is defined in more than
+// one place.
+func NewModule(sources *module.Sources) (*Module, error) {
+	m := &Module{Sources: sources, plans: make(map[string]*Signature)}
+	aliases := make(map[string]parser.Expression)
+	for _, file := range m.Files() {
+		for name, t := range TypeAliases(m.Expression(file)) {
+			aliases[name] = t
+		}
+	}
+
+	definedIn := make(map[string]string)
+	for _, file := range m.Files() {
+		program, ok := m.Expression(file).(*parser.Program)
+		if !ok {
+			continue
+		}
+		for _, d := range program.Definitions() {
+			plan, ok := d.(*parser.PlanDefinition)
+			if !ok {
+				continue
+			}
+			name := normalizeName(plan.Name())
+			if other, found := definedIn[name]; found {
+				return nil, issue.NewReported(PlanDuplicate, issue.SeverityError, issue.H{`name`: plan.Name(), `file`: other}, plan)
+			}
+			definedIn[name] = file
+			m.plans[name] = NewSignature(plan, aliases)
+		}
+	}
+	return m, nil
+}
+
+// Plan returns the signature of the plan with the given qualified name or nil if no such plan is defined in
+// the module. The name is case insensitive.
+func (m *Module) Plan(name string) *Signature {
+	return m.plans[normalizeName(name)]
+}
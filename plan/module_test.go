@@ -0,0 +1,58 @@
+package plan
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/module"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestNewModule(t *testing.T) {
+	m, err := parseModule(t, map[string]string{
+		`plans/init.pp`: `
+      plan mymod(TargetSpec $targets) {
+        run_plan('mymod::deploy', $targets, port => 80)
+      }`,
+		`plans/deploy.pp`: `
+      plan mymod::deploy(TargetSpec $targets, Mymod::Port $port) {}`,
+		`types/port.pp`: `
+      type Mymod::Port = Integer[1, 65535]`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Plan(`mymod`) == nil || m.Plan(`::Mymod::Deploy`) == nil {
+		t.Errorf(`expected plans 'mymod' and 'mymod::deploy' to be found`)
+	}
+	if m.Plan(`deploy`) != nil {
+		t.Errorf(`expected plan 'deploy' to not be found`)
+	}
+
+	// The alias is defined in a file that is parsed after the file of the plan
+	if m.Plan(`mymod::deploy`).Parameter(`port`).ResolvedType() == nil {
+		t.Errorf(`expected the type of parameter 'port' to be resolved`)
+	}
+}
+
+func TestNewModuleErrors(t *testing.T) {
+	_, err := parseModule(t, map[string]string{
+		`a.pp`: `plan mymod::a {}`,
+		`b.pp`: `plan mymod::a {}`})
+	if i, ok := err.(issue.Reported); !ok || i.Code() != PlanDuplicate {
+		t.Errorf(`expected %s, got %v`, PlanDuplicate, err)
+	}
+}
+
+// parseModule returns the module of the given sources, keyed by file name
+func parseModule(t *testing.T, files map[string]string) (*Module, error) {
+	t.Helper()
+	exprs := make(map[string]parser.Expression, len(files))
+	for file, source := range files {
+		expr, err := parser.CreateParser(parser.TasksEnabled).Parse(file, issue.Unindent(source), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exprs[file] = expr
+	}
+	return NewModule(module.New(exprs))
+}
@@ -0,0 +1,169 @@
+package plan
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type (
+	// A Signature describes the parameters of a plan in the same way that the metadata of a task describes
+	// the parameters of the task. It is written as JSON with the keys given by the field tags.
+	Signature struct {
+		// Name is the qualified name of the plan, e.g. 'mymodule::deploy'
+		Name string `json:"name"`
+
+		Parameters []*Parameter `json:"parameters"`
+
+		// ReturnType is the source of the declared return type of the plan
+		ReturnType string `json:"return_type,omitempty"`
+	}
+
+	// A Parameter is a parameter of a plan
+	Parameter struct {
+		Name string `json:"name"`
+
+		// Type is the source of the type expression, e.g. 'Array[String]'
+		Type string `json:"type,omitempty"`
+
+		// Default is the default value of the parameter when that value is literal
+		Default interface{} `json:"default,omitempty"`
+
+		// DefaultExpression is the source of the default value of the parameter when that value isn't literal
+		DefaultExpression string `json:"default_expression,omitempty"`
+
+		// Required is true when the parameter has no default value and its type doesn't accept undef
+		Required bool `json:"required"`
+
+		param *parser.Parameter
+		typ   literal.Type
+	}
+)
+
+// Signatures returns the signatures of the plans defined in the given expression in the order that they
+// appear in the source. The type aliases defined in the expression are used when resolving the types of
+// the parameters.
+func Signatures(e parser.Expression) []*Signature {
+	sigs := make([]*Signature, 0)
+	if program, ok := e.(*parser.Program); ok {
+		aliases := TypeAliases(program)
+		for _, d := range program.Definitions() {
+			if plan, ok := d.(*parser.PlanDefinition); ok {
+				sigs = append(sigs, NewSignature(plan, aliases))
+			}
+		}
+	}
+	return sigs
+}
+
+// TypeAliases returns the type expressions of the type aliases defined in the given expression keyed by
+// the lower case names of the aliases
+func TypeAliases(e parser.Expression) map[string]parser.Expression {
+	aliases := make(map[string]parser.Expression)
+	if program, ok := e.(*parser.Program); ok {
+		for _, d := range program.Definitions() {
+			if alias, ok := d.(*parser.TypeAlias); ok {
+				aliases[normalizeName(alias.Name())] = alias.Type()
+			}
+		}
+	}
+	return aliases
+}
+
+// NewSignature returns the signature of the given plan. The given aliases are used when resolving the types
+// of the parameters.
+func NewSignature(plan *parser.PlanDefinition, aliases map[string]parser.Expression) *Signature {
+	sig := &Signature{Name: plan.Name(), Parameters: make([]*Parameter, 0, len(plan.Parameters()))}
+	if plan.ReturnType() != nil {
+		sig.ReturnType = plan.ReturnType().String()
+	}
+	for _, e := range plan.Parameters() {
+		p, ok := e.(*parser.Parameter)
+		if !ok {
+			continue
+		}
+		sp := &Parameter{Name: p.Name(), param: p}
+		if p.Type() != nil {
+			sp.Type = p.Type().String()
+			if t, err := literal.ResolveType(p.Type(), aliases); err == nil {
+				sp.typ = t
+			}
+		}
+		if p.Value() != nil {
+			if v, ok := literal.ToLiteral(p.Value()); ok {
+				sp.Default = jsonValue(v)
+			} else {
+				sp.DefaultExpression = parser.SourceOf(p.Value())
+			}
+		} else {
+			// A parameter without a type is required. So is a parameter whose type is unknown.
+			sp.Required = sp.typ == nil || !sp.typ.IsInstance(nil)
+		}
+		sig.Parameters = append(sig.Parameters, sp)
+	}
+	return sig
+}
+
+// Parameter returns the parameter with the given name or nil if the plan has no such parameter
+func (s *Signature) Parameter(name string) *Parameter {
+	for _, p := range s.Parameters {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// ParameterNames returns the names of the parameters of the plan in declaration order
+func (s *Signature) ParameterNames() []string {
+	names := make([]string, len(s.Parameters))
+	for i, p := range s.Parameters {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// Expression returns the parameter of the plan definition
+func (p *Parameter) Expression() *parser.Parameter {
+	return p.param
+}
+
+// ResolvedType returns the resolved type of the parameter or nil if the parameter has no type or if its type
+// cannot be resolved, e.g. because it is a type that is only known at runtime such as TargetSpec
+func (p *Parameter) ResolvedType() literal.Type {
+	return p.typ
+}
+
+// WriteSignaturesJSON writes the given signatures as an indented JSON array
+func WriteSignaturesJSON(sigs []*Signature, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(``, `  `)
+	return enc.Encode(sigs)
+}
+
+// jsonValue returns the given literal value with the keys of its hashes converted to strings
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, e := range v {
+			result[i] = jsonValue(e)
+		}
+		return result
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			result[literal.ToString(k)] = jsonValue(e)
+		}
+		return result
+	}
+	return v
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimPrefix(name, `::`))
+}
@@ -0,0 +1,107 @@
+package plan
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestSignatures(t *testing.T) {
+	sigs := Signatures(parse(t, issue.Unindent(`
+    type Mymod::Port = Integer[1, 65535]
+
+    plan mymod::deploy(
+      TargetSpec $targets,
+      Mymod::Port $port = 8080,
+      Optional[String] $note,
+      Hash $opts = {'a' => 1, 2 => [true]},
+      $version = $facts['version'],
+      Boolean $force = false
+    ) >> Boolean {
+      true
+    }
+
+    function mymod::helper() {}
+
+    plan mymod::empty {}`)))
+
+	if len(sigs) != 2 || sigs[0].Name != `mymod::deploy` || sigs[1].Name != `mymod::empty` {
+		t.Fatalf(`unexpected signatures %v`, sigs)
+	}
+	if sigs[0].Parameter(`port`).ResolvedType() == nil || sigs[0].Parameter(`targets`).ResolvedType() != nil {
+		t.Errorf(`expected the alias to be resolved and TargetSpec to be unresolved`)
+	}
+	if sigs[0].Parameter(`nosuch`) != nil {
+		t.Errorf(`expected parameter 'nosuch' to not be found`)
+	}
+
+	b := bytes.NewBufferString(``)
+	if err := WriteSignaturesJSON(sigs, b); err != nil {
+		t.Fatal(err)
+	}
+	expected := issue.Unindent(`
+    [
+      {
+        "name": "mymod::deploy",
+        "parameters": [
+          {
+            "name": "targets",
+            "type": "TargetSpec",
+            "required": true
+          },
+          {
+            "name": "port",
+            "type": "Mymod::Port",
+            "default": 8080,
+            "required": false
+          },
+          {
+            "name": "note",
+            "type": "Optional[String]",
+            "required": false
+          },
+          {
+            "name": "opts",
+            "type": "Hash",
+            "default": {
+              "2": [
+                true
+              ],
+              "a": 1
+            },
+            "required": false
+          },
+          {
+            "name": "version",
+            "default_expression": "$facts['version']",
+            "required": false
+          },
+          {
+            "name": "force",
+            "type": "Boolean",
+            "default": false,
+            "required": false
+          }
+        ],
+        "return_type": "Boolean"
+      },
+      {
+        "name": "mymod::empty",
+        "parameters": []
+      }
+    ]`) + "\n"
+	if b.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+}
+
+func parse(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	expr, err := parser.CreateParser(parser.TasksEnabled).Parse(``, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr
+}
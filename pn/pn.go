@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/lyraproj/issue/issue"
@@ -79,12 +80,85 @@ type (
 
 var keyPattern = regexp.MustCompile(`^[A-Za-z_-][0-9A-Za-z_-]*$`)
 
-// Represent the Reported using Puppet Extended S-Expression Notation (PN)
+// SourceRange is implemented by locations that know the range of the source that they span, such as
+// the expressions produced by the parser
+type SourceRange interface {
+	// ByteOffset returns the offset of the start of the range
+	ByteOffset() int
+
+	// EndOffset returns the offset of the end of the range
+	EndOffset() int
+
+	// EndLine returns the line of the end of the range
+	EndLine() int
+
+	// EndPos returns the position that follows the end of the range on its end line
+	EndPos() int
+}
+
+// Represent the Reported using Puppet Extended S-Expression Notation (PN). In addition to the code,
+// severity, and message, the map contains the file, line, and column of the location when known,
+// the end line and column, byte offset, and byte length when the location is a SourceRange, the
+// label when the location is an expression, and the arguments of the issue.
 func ReportedToPN(ri issue.Reported) PN {
-	return Map([]Entry{
+	entries := []Entry{
 		Literal(ri.Code()).WithName(`code`),
 		Literal(ri.Severity().String()).WithName(`severity`),
-		Literal(ri.Error()).WithName(`message`)})
+		Literal(ri.Error()).WithName(`message`)}
+
+	if loc := ri.Location(); loc != nil {
+		if loc.File() != `` {
+			entries = append(entries, Literal(loc.File()).WithName(`file`))
+		}
+		if loc.Line() > 0 {
+			entries = append(entries, Literal(loc.Line()).WithName(`line`))
+			if loc.Pos() > 0 {
+				entries = append(entries, Literal(loc.Pos()).WithName(`column`))
+			}
+			if sr, ok := loc.(SourceRange); ok {
+				entries = append(entries,
+					Literal(sr.EndLine()).WithName(`end_line`),
+					Literal(sr.EndPos()).WithName(`end_column`),
+					Literal(sr.ByteOffset()).WithName(`offset`),
+					Literal(sr.EndOffset()-sr.ByteOffset()).WithName(`length`))
+			}
+		}
+		if e, ok := loc.(expression); ok {
+			entries = append(entries, Literal(e.Label()).WithName(`label`))
+		}
+	}
+
+	if keys := ri.Keys(); len(keys) > 0 {
+		keys = append([]string{}, keys...)
+		sort.Strings(keys)
+		args := make([]Entry, len(keys))
+		for i, key := range keys {
+			args[i] = argumentToPN(ri.Argument(key)).WithName(key)
+		}
+		entries = append(entries, Map(args).WithName(`arguments`))
+	}
+	return Map(entries)
+}
+
+// expression is implemented by the expressions produced by the parser
+type expression interface {
+	issue.Labeled
+	ToPN() PN
+}
+
+func argumentToPN(arg interface{}) PN {
+	switch arg := arg.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return Literal(arg)
+	case issue.Code:
+		return Literal(string(arg))
+	case issue.Severity:
+		return Literal(arg.String())
+	case fmt.Stringer:
+		return Literal(arg.String())
+	default:
+		return Literal(fmt.Sprintf(`%v`, arg))
+	}
 }
 
 func (e *pnError) Error() string {
@@ -0,0 +1,84 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+// CheckstyleVersion is the version of the Checkstyle format written by the Checkstyle reporter
+const CheckstyleVersion = `4.3`
+
+type (
+	checkstyleResult struct {
+		XMLName xml.Name          `xml:"checkstyle"`
+		Version string            `xml:"version,attr"`
+		Files   []*checkstyleFile `xml:"file"`
+	}
+
+	checkstyleFile struct {
+		Name   string             `xml:"name,attr"`
+		Errors []*checkstyleError `xml:"error"`
+	}
+
+	checkstyleError struct {
+		Line     int    `xml:"line,attr"`
+		Column   int    `xml:"column,attr,omitempty"`
+		Severity string `xml:"severity,attr"`
+		Message  string `xml:"message,attr"`
+		Source   string `xml:"source,attr"`
+	}
+
+	checkstyleReporter struct {
+		w io.Writer
+	}
+)
+
+// NewCheckstyleReporter returns a Reporter that writes the issues as Checkstyle XML with one error
+// element for each issue. The issue code is used as the source of the error.
+func NewCheckstyleReporter(w io.Writer) Reporter {
+	return &checkstyleReporter{w}
+}
+
+func (r *checkstyleReporter) Report(file string, issues []issue.Reported, output Output) error {
+	files := []*checkstyleFile{{Name: file, Errors: []*checkstyleError{}}}
+	for _, i := range issues {
+		f := files[0]
+		if loc := i.Location(); loc != nil && loc.File() != `` && loc.File() != file {
+			f = nil
+			for _, cf := range files {
+				if cf.Name == loc.File() {
+					f = cf
+					break
+				}
+			}
+			if f == nil {
+				f = &checkstyleFile{Name: loc.File()}
+				files = append(files, f)
+			}
+		}
+		region := RegionOf(i.Location())
+		f.Errors = append(f.Errors, &checkstyleError{
+			Line:     region.StartLine,
+			Column:   region.StartColumn,
+			Severity: checkstyleSeverity(i.Severity()),
+			Message:  Message(i),
+			Source:   string(i.Code()),
+		})
+	}
+	return writeXML(r.w, &checkstyleResult{Version: CheckstyleVersion, Files: files})
+}
+
+func checkstyleSeverity(severity issue.Severity) string {
+	switch severity {
+	case issue.SeverityError:
+		return `error`
+	case issue.SeverityWarning:
+		return `warning`
+	case issue.SeverityDeprecation:
+		return `info`
+	default:
+		return `ignore`
+	}
+}
@@ -0,0 +1,162 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+// MaxSnippetLines is the maximum number of source lines shown for an issue by the diagnostic reporter.
+// Only the first and the last line are shown for an expression that spans more lines.
+const MaxSnippetLines = 4
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiCyan   = "\x1b[1;36m"
+	ansiBlue   = "\x1b[1;34m"
+)
+
+type diagnosticReporter struct {
+	issues io.Writer
+	output io.Writer
+	lines  []string
+	color  bool
+}
+
+// NewDiagnosticReporter returns a Reporter that writes each issue to the given issues writer in the style
+// of a compiler diagnostic. The severity, code, and message are followed by the location and the lines of
+// the given source that contain the offending expression, which is underlined with carets. The severity
+// and the carets are colored using ANSI escape sequences when color is true. The output is written as text
+// to the given output writer.
+func NewDiagnosticReporter(issues, output io.Writer, source string, color bool) Reporter {
+	return &diagnosticReporter{issues, output, strings.Split(source, "\n"), color}
+}
+
+func (r *diagnosticReporter) Report(file string, issues []issue.Reported, output Output) error {
+	b := bytes.NewBufferString(``)
+	for _, i := range issues {
+		r.writeIssue(b, file, i)
+	}
+	if _, err := r.issues.Write(b.Bytes()); err != nil {
+		return err
+	}
+	if output != nil {
+		return output.WriteText(r.output)
+	}
+	return nil
+}
+
+func (r *diagnosticReporter) writeIssue(b *bytes.Buffer, file string, i issue.Reported) {
+	severityColor := r.severityColor(i.Severity())
+	r.colored(b, severityColor, fmt.Sprintf(`%s[%s]`, i.Severity(), i.Code()))
+	r.colored(b, ansiBold, `: `+Message(i))
+	b.WriteByte('\n')
+
+	loc := i.Location()
+	if loc == nil || loc.File() == `` && loc.Line() <= 0 {
+		return
+	}
+	region := RegionOf(loc)
+	if region.IsEmpty() {
+		r.colored(b, ansiBlue, `  --> `)
+		b.WriteString(loc.File())
+		b.WriteByte('\n')
+		return
+	}
+
+	first, last := region.StartLine, region.EndLine
+	if region.EndColumn == 1 && last > first {
+		// The range ends with a line break
+		last--
+	}
+	gutter := len(strconv.Itoa(last)) + 1
+	r.colored(b, ansiBlue, strings.Repeat(` `, gutter-1)+`--> `)
+	fmt.Fprintf(b, "%s:%d:%d\n", loc.File(), region.StartLine, region.StartColumn)
+	if loc.File() != file || last > len(r.lines) {
+		// Source is not available
+		return
+	}
+
+	r.colored(b, ansiBlue, strings.Repeat(` `, gutter)+"|\n")
+	for line := first; line <= last; line++ {
+		if last-first >= MaxSnippetLines && line == first+1 {
+			r.colored(b, ansiBlue, "...\n")
+			line = last
+		}
+		text := strings.TrimSuffix(r.lines[line-1], "\r")
+		start := 1
+		if line == first {
+			start = region.StartColumn
+		} else {
+			// Don't underline the indentation of continuation lines
+			start = utf8.RuneCountInString(text) - utf8.RuneCountInString(strings.TrimLeft(text, " \t")) + 1
+		}
+		end := utf8.RuneCountInString(text) + 1
+		if line == region.EndLine {
+			end = region.EndColumn
+		}
+		if end <= start {
+			// Point at the position when the range is empty
+			end = start + 1
+		}
+
+		r.colored(b, ansiBlue, fmt.Sprintf(`%*d | `, gutter-1, line))
+		b.WriteString(text)
+		b.WriteByte('\n')
+		r.colored(b, ansiBlue, strings.Repeat(` `, gutter)+`| `)
+		b.WriteString(padding(text, start))
+		r.colored(b, severityColor, strings.Repeat(`^`, end-start))
+		b.WriteByte('\n')
+	}
+}
+
+// padding returns whitespace that aligns with the given column of the given line. Tabs are retained so
+// that the alignment is correct regardless of the tab width.
+func padding(text string, column int) string {
+	b := bytes.NewBufferString(``)
+	n := 1
+	for _, c := range text {
+		if n >= column {
+			break
+		}
+		if c == '\t' {
+			b.WriteByte('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+		n++
+	}
+	for ; n < column; n++ {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+func (r *diagnosticReporter) severityColor(severity issue.Severity) string {
+	switch severity {
+	case issue.SeverityError:
+		return ansiRed
+	case issue.SeverityWarning:
+		return ansiYellow
+	default:
+		return ansiCyan
+	}
+}
+
+func (r *diagnosticReporter) colored(b *bytes.Buffer, color, text string) {
+	if r.color {
+		b.WriteString(color)
+		b.WriteString(text)
+		b.WriteString(ansiReset)
+	} else {
+		b.WriteString(text)
+	}
+}
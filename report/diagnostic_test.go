@@ -0,0 +1,77 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestDiagnosticReporter(t *testing.T) {
+	source := "notice(\"a\")\n$x += [1,\n  2]\n"
+	issues, output := bytes.NewBufferString(``), bytes.NewBufferString(``)
+	expectNoError(t, NewDiagnosticReporter(issues, output, source, false).Report(`dir/test.pp`, validate(t, source), testOutput{}))
+	expectOutput(t, issues, `warning[VALIDATE_DOUBLE_QUOTED_STRING]: Double quoted string without interpolation. Use single quotes for literal strings
+ --> dir/test.pp:1:8
+  |
+1 | notice("a")
+  |        ^^^
+error[VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED]: The operator '+=' is no longer supported. See http://links.puppet.com/remove-plus-equals
+ --> dir/test.pp:2:1
+  |
+2 | $x += [1,
+  | ^^^^^^^^^
+3 |   2]
+  |   ^^
+`)
+	expectOutput(t, output, "output\n")
+}
+
+func TestDiagnosticReporterLongRange(t *testing.T) {
+	source := "class a {\n  notice(1)\n  notice(2)\n  notice(3)\n  notice(4)\n}\n"
+	ri := issue.NewReported(`VALIDATE_DOUBLE_QUOTED_STRING`, issue.SeverityDeprecation, issue.NoArgs, parse(t, source).Body())
+	b := bytes.NewBufferString(``)
+	expectNoError(t, NewDiagnosticReporter(b, b, source, false).Report(`dir/test.pp`, []issue.Reported{ri}, nil))
+	expectOutput(t, b, `deprecation[VALIDATE_DOUBLE_QUOTED_STRING]: Double quoted string without interpolation. Use single quotes for literal strings
+ --> dir/test.pp:1:7
+  |
+1 | class a {
+  |       ^^^
+...
+6 | }
+  | ^
+`)
+}
+
+func TestDiagnosticReporterParseError(t *testing.T) {
+	source := "$x = \"abc\n"
+	_, err := parser.CreateParser().Parse(`dir/test.pp`, source, false)
+	b := bytes.NewBufferString(``)
+	expectNoError(t, NewDiagnosticReporter(b, b, source, true).Report(`dir/test.pp`, []issue.Reported{err.(issue.Reported)}, nil))
+	expectOutput(t, b, "\x1b[1;31merror[LEX_UNTERMINATED_STRING]\x1b[0m\x1b[1m: unterminated double quoted string\x1b[0m\n"+
+		"\x1b[1;34m --> \x1b[0mdir/test.pp:1:6\n"+
+		"\x1b[1;34m  |\n\x1b[0m"+
+		"\x1b[1;34m1 | \x1b[0m$x = \"abc\n"+
+		"\x1b[1;34m  | \x1b[0m     \x1b[1;31m^\x1b[0m\n")
+}
+
+func TestDiagnosticReporterTabs(t *testing.T) {
+	source := "\tnotice(\"a\")\n"
+	b := bytes.NewBufferString(``)
+	expectNoError(t, NewDiagnosticReporter(b, b, source, false).Report(`dir/test.pp`, validate(t, source), nil))
+	expectOutput(t, b, "warning[VALIDATE_DOUBLE_QUOTED_STRING]: Double quoted string without interpolation. Use single quotes for literal strings\n"+
+		" --> dir/test.pp:1:9\n"+
+		"  |\n"+
+		"1 | \tnotice(\"a\")\n"+
+		"  | \t       ^^^\n")
+}
+
+func parse(t *testing.T, source string) *parser.Program {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`dir/test.pp`, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr.(*parser.Program)
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+type (
+	junitTestSuites struct {
+		XMLName xml.Name          `xml:"testsuites"`
+		Suites  []*junitTestSuite `xml:"testsuite"`
+	}
+
+	junitTestSuite struct {
+		Name      string           `xml:"name,attr"`
+		Tests     int              `xml:"tests,attr"`
+		Failures  int              `xml:"failures,attr"`
+		Errors    int              `xml:"errors,attr"`
+		TestCases []*junitTestCase `xml:"testcase"`
+	}
+
+	junitTestCase struct {
+		Name      string          `xml:"name,attr"`
+		ClassName string          `xml:"classname,attr"`
+		Failures  []*junitFailure `xml:"failure"`
+		SystemOut *junitText      `xml:"system-out"`
+	}
+
+	junitFailure struct {
+		Message string `xml:"message,attr"`
+		Type    string `xml:"type,attr"`
+		Text    string `xml:",chardata"`
+	}
+
+	junitText struct {
+		Text string `xml:",cdata"`
+	}
+
+	junitReporter struct {
+		w io.Writer
+	}
+)
+
+// NewJUnitReporter returns a Reporter that writes the issues as JUnit XML. The file is a test case
+// with one failure for each issue of severity error. Issues of lower severity are written to the
+// standard output of the test case.
+func NewJUnitReporter(w io.Writer) Reporter {
+	return &junitReporter{w}
+}
+
+func (r *junitReporter) Report(file string, issues []issue.Reported, output Output) error {
+	tc := &junitTestCase{Name: file, ClassName: toolName, Failures: []*junitFailure{}}
+	out := make([]string, 0)
+	for _, i := range issues {
+		if i.Severity() == issue.SeverityError {
+			tc.Failures = append(tc.Failures, &junitFailure{Message: Message(i), Type: string(i.Code()), Text: i.String()})
+		} else {
+			out = append(out, i.Severity().String()+`: `+i.String())
+		}
+	}
+	if len(out) > 0 {
+		tc.SystemOut = &junitText{strings.Join(out, "\n")}
+	}
+
+	suite := &junitTestSuite{Name: toolName, Tests: 1, Failures: len(tc.Failures), TestCases: []*junitTestCase{tc}}
+	return writeXML(r.w, &junitTestSuites{Suites: []*junitTestSuite{suite}})
+}
+
+func writeXML(w io.Writer, value interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent(``, `  `)
+	if err := enc.Encode(value); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
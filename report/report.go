@@ -0,0 +1,65 @@
+// Package report writes the issues found by the parser and the validator in formats that are consumed
+// by other tools.
+package report
+
+import (
+	"bytes"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/pn"
+)
+
+// A Region is the range of the source that an issue applies to. Lines and columns start at 1 and the
+// columns count unicode code points. The end is exclusive. Offset and Length are in bytes and are -1
+// when the location of the issue doesn't know its source.
+type Region struct {
+	StartLine   int
+	StartColumn int
+	EndLine     int
+	EndColumn   int
+	Offset      int
+	Length      int
+}
+
+// Message returns the message of the given issue without its location
+func Message(ri issue.Reported) string {
+	i, ok := issue.ForCode2(ri.Code())
+	if !ok {
+		return string(ri.Code())
+	}
+	args := make(issue.H, len(ri.Keys()))
+	for _, key := range ri.Keys() {
+		args[key] = ri.Argument(key)
+	}
+	b := bytes.NewBufferString(``)
+	i.Format(b, args)
+	return b.String()
+}
+
+// RegionOf returns the region of the given location. The region is empty when the location has
+// no line.
+func RegionOf(location issue.Location) Region {
+	if location == nil || location.Line() <= 0 {
+		return Region{Offset: -1, Length: -1}
+	}
+	if s, ok := location.(pn.SourceRange); ok {
+		return Region{
+			StartLine:   location.Line(),
+			StartColumn: location.Pos(),
+			EndLine:     s.EndLine(),
+			EndColumn:   s.EndPos(),
+			Offset:      s.ByteOffset(),
+			Length:      s.EndOffset() - s.ByteOffset(),
+		}
+	}
+	column := location.Pos()
+	if column <= 0 {
+		column = 1
+	}
+	return Region{StartLine: location.Line(), StartColumn: column, EndLine: location.Line(), EndColumn: column, Offset: -1, Length: -1}
+}
+
+// IsEmpty returns true if the region has no line
+func (r Region) IsEmpty() bool {
+	return r.StartLine <= 0
+}
@@ -0,0 +1,92 @@
+package report
+
+import (
+	"io"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/json"
+	"github.com/lyraproj/puppet-parser/pn"
+)
+
+type (
+	// A Reporter writes the issues found in a file in some format. Reporters that support it also write
+	// the output produced from the file, such as its AST.
+	Reporter interface {
+		// Report writes the given issues found in the given file. The output is nil when there is
+		// nothing to write besides the issues.
+		Report(file string, issues []issue.Reported, output Output) error
+	}
+
+	// Output is something produced from a parsed file, such as its AST or its documentation
+	Output interface {
+		// Key returns the key of the output in a JSON object
+		Key() string
+
+		// WriteText writes the output as text
+		WriteText(w io.Writer) error
+
+		// ToData returns the output in a form that can be written as JSON
+		ToData() interface{}
+	}
+
+	textReporter struct {
+		issues io.Writer
+		output io.Writer
+	}
+
+	jsonReporter struct {
+		w io.Writer
+	}
+)
+
+// NewTextReporter returns a Reporter that writes one issue per line to the given issues writer and
+// the output as text to the given output writer
+func NewTextReporter(issues, output io.Writer) Reporter {
+	return &textReporter{issues, output}
+}
+
+// NewJSONReporter returns a Reporter that writes a JSON object with the issues in an "issues" array
+// and the output under its key. The "issues" key is only present when there are issues.
+func NewJSONReporter(w io.Writer) Reporter {
+	return &jsonReporter{w}
+}
+
+// MaxSeverity returns the highest severity of the given issues or SeverityIgnore when there are
+// no issues
+func MaxSeverity(issues []issue.Reported) issue.Severity {
+	severity := issue.Severity(issue.SeverityIgnore)
+	for _, i := range issues {
+		if i.Severity() > severity {
+			severity = i.Severity()
+		}
+	}
+	return severity
+}
+
+func (r *textReporter) Report(file string, issues []issue.Reported, output Output) error {
+	for _, i := range issues {
+		if _, err := io.WriteString(r.issues, i.String()+"\n"); err != nil {
+			return err
+		}
+	}
+	if output != nil {
+		return output.WriteText(r.output)
+	}
+	return nil
+}
+
+func (r *jsonReporter) Report(file string, issues []issue.Reported, output Output) error {
+	result := make(map[string]interface{}, 2)
+	if len(issues) > 0 {
+		data := make([]interface{}, len(issues))
+		for idx, i := range issues {
+			data[idx] = pn.ReportedToPN(i).ToData()
+		}
+		result[`issues`] = data
+	}
+	if output != nil {
+		result[output.Key()] = output.ToData()
+	}
+	json.ToJson(result, r.w)
+	return nil
+}
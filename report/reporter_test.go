@@ -0,0 +1,117 @@
+package report
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+type testOutput struct{}
+
+func (testOutput) Key() string {
+	return `test`
+}
+
+func (testOutput) WriteText(w io.Writer) error {
+	_, err := io.WriteString(w, "output\n")
+	return err
+}
+
+func (testOutput) ToData() interface{} {
+	return []int{1, 2}
+}
+
+func TestTextReporter(t *testing.T) {
+	issues, output := bytes.NewBufferString(``), bytes.NewBufferString(``)
+	expectNoError(t, NewTextReporter(issues, output).Report(`dir/test.pp`, validate(t, `notice("a")`), testOutput{}))
+	expectOutput(t, issues, "Double quoted string without interpolation. Use single quotes for literal strings (file: dir/test.pp, line: 1, column: 8)\n")
+	expectOutput(t, output, "output\n")
+}
+
+func TestJSONReporter(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	expectNoError(t, NewJSONReporter(b).Report(`dir/test.pp`, validate(t, `notice("a")`), testOutput{}))
+	expectOutput(t, b, `{"issues":[{"#":["code","VALIDATE_DOUBLE_QUOTED_STRING","severity","warning","message",`+
+		`"Double quoted string without interpolation. Use single quotes for literal strings (file: dir/test.pp, line: 1, column: 8)",`+
+		`"file","dir/test.pp","line",1,"column",8,"end_line",1,"end_column",11,"offset",7,"length",3,"label","Literal String"]}],"test":[1,2]}`+"\n")
+
+	b.Reset()
+	expectNoError(t, NewJSONReporter(b).Report(`dir/test.pp`, validate(t, "$x += [1,\n  2]\n"), nil))
+	expectOutput(t, b, `{"issues":[{"#":["code","VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED","severity","error","message",`+
+		`"The operator '+=' is no longer supported. See http://links.puppet.com/remove-plus-equals (file: dir/test.pp, line: 1, column: 1)",`+
+		`"file","dir/test.pp","line",1,"column",1,"end_line",2,"end_column",5,"offset",0,"length",14,"label","'+=' expression",`+
+		`"arguments",{"#":["operator","+="]}]}]}`+"\n")
+
+	b.Reset()
+	expectNoError(t, NewJSONReporter(b).Report(`dir/test.pp`, nil, nil))
+	expectOutput(t, b, "{}\n")
+}
+
+func TestJUnitReporter(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	expectNoError(t, NewJUnitReporter(b).Report(`dir/test.pp`, validate(t, "notice(\"a\")\n$x += 1\n"), testOutput{}))
+	expectOutput(t, b, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="puppet-parser" tests="1" failures="1" errors="0">
+    <testcase name="dir/test.pp" classname="puppet-parser">
+      <failure message="The operator &#39;+=&#39; is no longer supported. See http://links.puppet.com/remove-plus-equals" type="VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED">The operator &#39;+=&#39; is no longer supported. See http://links.puppet.com/remove-plus-equals (file: dir/test.pp, line: 2, column: 1)</failure>
+      <system-out><![CDATA[warning: Double quoted string without interpolation. Use single quotes for literal strings (file: dir/test.pp, line: 1, column: 8)]]></system-out>
+    </testcase>
+  </testsuite>
+</testsuites>
+`)
+
+	b.Reset()
+	expectNoError(t, NewJUnitReporter(b).Report(`dir/test.pp`, nil, nil))
+	expectOutput(t, b, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="puppet-parser" tests="1" failures="0" errors="0">
+    <testcase name="dir/test.pp" classname="puppet-parser"></testcase>
+  </testsuite>
+</testsuites>
+`)
+}
+
+func TestCheckstyleReporter(t *testing.T) {
+	issues := validate(t, "notice(\"a\")\n$x += 1\n")
+	issues = append(issues, issue.NewReported(`VALIDATE_DOUBLE_QUOTED_STRING`, issue.SeverityDeprecation, issue.NoArgs, issue.NewLocation(`other.pp`, 3, 0)))
+
+	b := bytes.NewBufferString(``)
+	expectNoError(t, NewCheckstyleReporter(b).Report(`dir/test.pp`, issues, nil))
+	expectOutput(t, b, `<?xml version="1.0" encoding="UTF-8"?>
+<checkstyle version="4.3">
+  <file name="dir/test.pp">
+    <error line="1" column="8" severity="warning" message="Double quoted string without interpolation. Use single quotes for literal strings" source="VALIDATE_DOUBLE_QUOTED_STRING"></error>
+    <error line="2" column="1" severity="error" message="The operator &#39;+=&#39; is no longer supported. See http://links.puppet.com/remove-plus-equals" source="VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED"></error>
+  </file>
+  <file name="other.pp">
+    <error line="3" column="1" severity="info" message="Double quoted string without interpolation. Use single quotes for literal strings" source="VALIDATE_DOUBLE_QUOTED_STRING"></error>
+  </file>
+</checkstyle>
+`)
+}
+
+func TestMaxSeverity(t *testing.T) {
+	if s := MaxSeverity(nil); s != issue.SeverityIgnore {
+		t.Errorf(`expected ignore, got %s`, s)
+	}
+	if s := MaxSeverity(validate(t, "notice(\"a\")\n$x += 1\n")); s != issue.SeverityError {
+		t.Errorf(`expected error, got %s`, s)
+	}
+}
+
+func expectNoError(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectOutput(t *testing.T, b *bytes.Buffer, expected string) {
+	t.Helper()
+	if s := b.String(); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+}
@@ -0,0 +1,177 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+const (
+	// SarifVersion is the version of the SARIF format written by WriteSarif
+	SarifVersion = `2.1.0`
+
+	// SarifSchema is the JSON schema of the SARIF format written by WriteSarif
+	SarifSchema = `https://json.schemastore.org/sarif-2.1.0.json`
+
+	toolName = `puppet-parser`
+	toolURI  = `https://github.com/lyraproj/puppet-parser`
+)
+
+type (
+	sarifLog struct {
+		Schema  string      `json:"$schema"`
+		Version string      `json:"version"`
+		Runs    []*sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool       sarifTool      `json:"tool"`
+		ColumnKind string         `json:"columnKind"`
+		Results    []*sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string       `json:"name"`
+		InformationURI string       `json:"informationUri"`
+		Rules          []*sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifResult struct {
+		RuleID    string           `json:"ruleId"`
+		RuleIndex int              `json:"ruleIndex"`
+		Level     string           `json:"level"`
+		Message   sarifMessage     `json:"message"`
+		Locations []*sarifLocation `json:"locations,omitempty"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+
+	sarifRegion struct {
+		StartLine   int  `json:"startLine"`
+		StartColumn int  `json:"startColumn"`
+		EndLine     int  `json:"endLine"`
+		EndColumn   int  `json:"endColumn"`
+		ByteOffset  *int `json:"byteOffset,omitempty"`
+		ByteLength  *int `json:"byteLength,omitempty"`
+	}
+
+	sarifReporter struct {
+		w io.Writer
+	}
+)
+
+// NewSarifReporter returns a Reporter that writes the issues with WriteSarif
+func NewSarifReporter(w io.Writer) Reporter {
+	return &sarifReporter{w}
+}
+
+func (r *sarifReporter) Report(file string, issues []issue.Reported, output Output) error {
+	return WriteSarif(r.w, issues)
+}
+
+// WriteSarif writes the given issues as a SARIF log with one run. The run declares one rule for each
+// issue code, described by the message format of the issue.
+func WriteSarif(w io.Writer, issues []issue.Reported) error {
+	codes := make([]string, 0)
+	seen := make(map[issue.Code]bool)
+	for _, ri := range issues {
+		if !seen[ri.Code()] {
+			seen[ri.Code()] = true
+			codes = append(codes, string(ri.Code()))
+		}
+	}
+	sort.Strings(codes)
+
+	ruleIndex := make(map[issue.Code]int, len(codes))
+	rules := make([]*sarifRule, len(codes))
+	for i, code := range codes {
+		ruleIndex[issue.Code(code)] = i
+		description := code
+		if is, ok := issue.ForCode2(issue.Code(code)); ok {
+			description = is.MessageFormat()
+		}
+		rules[i] = &sarifRule{ID: code, ShortDescription: sarifMessage{description}}
+	}
+
+	results := make([]*sarifResult, len(issues))
+	for i, ri := range issues {
+		results[i] = &sarifResult{
+			RuleID:    string(ri.Code()),
+			RuleIndex: ruleIndex[ri.Code()],
+			Level:     sarifLevel(ri.Severity()),
+			Message:   sarifMessage{Message(ri)},
+			Locations: sarifLocations(ri.Location()),
+		}
+	}
+
+	log := &sarifLog{
+		Schema:  SarifSchema,
+		Version: SarifVersion,
+		Runs: []*sarifRun{{
+			Tool:       sarifTool{sarifDriver{Name: toolName, InformationURI: toolURI, Rules: rules}},
+			ColumnKind: `unicodeCodePoints`,
+			Results:    results,
+		}},
+	}
+	content, err := json.MarshalIndent(log, ``, `  `)
+	if err == nil {
+		_, err = w.Write(append(content, '\n'))
+	}
+	return err
+}
+
+func sarifLevel(severity issue.Severity) string {
+	switch severity {
+	case issue.SeverityError:
+		return `error`
+	case issue.SeverityWarning:
+		return `warning`
+	case issue.SeverityDeprecation:
+		return `note`
+	default:
+		return `none`
+	}
+}
+
+func sarifLocations(location issue.Location) []*sarifLocation {
+	if location == nil || location.File() == `` {
+		return nil
+	}
+	pl := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{filepath.ToSlash(location.File())}}
+	if r := RegionOf(location); !r.IsEmpty() {
+		sr := &sarifRegion{StartLine: r.StartLine, StartColumn: r.StartColumn, EndLine: r.EndLine, EndColumn: r.EndColumn}
+		if r.Offset >= 0 {
+			sr.ByteOffset = &r.Offset
+			sr.ByteLength = &r.Length
+		}
+		pl.Region = sr
+	}
+	return []*sarifLocation{{PhysicalLocation: pl}}
+}
@@ -0,0 +1,113 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/validator"
+)
+
+func TestWriteSarif(t *testing.T) {
+	issues := validate(t, "notice(\"a\")\nnotice(\"b\")\n$x += 1\n")
+
+	b := bytes.NewBufferString(``)
+	if err := WriteSarif(b, issues); err != nil {
+		t.Fatal(err)
+	}
+	var log map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log[`version`] != SarifVersion {
+		t.Errorf(`unexpected version %v`, log[`version`])
+	}
+
+	run := log[`runs`].([]interface{})[0].(map[string]interface{})
+	rules := run[`tool`].(map[string]interface{})[`driver`].(map[string]interface{})[`rules`].([]interface{})
+	if len(rules) != 2 {
+		t.Fatalf(`expected 2 rules, got %v`, rules)
+	}
+	rule := rules[1].(map[string]interface{})
+	if rule[`id`] != string(validator.ValidateDoubleQuotedString) {
+		t.Errorf(`unexpected rule %v`, rule)
+	}
+
+	results := run[`results`].([]interface{})
+	if len(results) != 3 {
+		t.Fatalf(`expected 3 results, got %v`, results)
+	}
+	expectJSON(t, results[2], `{
+  "level": "error",
+  "locations": [
+    {
+      "physicalLocation": {
+        "artifactLocation": {
+          "uri": "dir/test.pp"
+        },
+        "region": {
+          "byteLength": 7,
+          "byteOffset": 24,
+          "endColumn": 8,
+          "endLine": 3,
+          "startColumn": 1,
+          "startLine": 3
+        }
+      }
+    }
+  ],
+  "message": {
+    "text": "The operator '+=' is no longer supported. See http://links.puppet.com/remove-plus-equals"
+  },
+  "ruleId": "VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED",
+  "ruleIndex": 0
+}`)
+	if level := results[1].(map[string]interface{})[`level`]; level != `warning` {
+		t.Errorf(`expected level warning, got %v`, level)
+	}
+}
+
+func TestWriteSarifParseError(t *testing.T) {
+	_, err := parser.CreateParser().Parse(`test.pp`, `notice(`, false)
+	b := bytes.NewBufferString(``)
+	if err := WriteSarif(b, []issue.Reported{err.(issue.Reported)}); err != nil {
+		t.Fatal(err)
+	}
+	var log map[string]interface{}
+	if err := json.Unmarshal(b.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	result := log[`runs`].([]interface{})[0].(map[string]interface{})[`results`].([]interface{})[0]
+	region := result.(map[string]interface{})[`locations`].([]interface{})[0].(map[string]interface{})[`physicalLocation`].(map[string]interface{})[`region`]
+	expectJSON(t, region, `{
+  "endColumn": 8,
+  "endLine": 1,
+  "startColumn": 8,
+  "startLine": 1
+}`)
+}
+
+func validate(t *testing.T, source string) []issue.Reported {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(`dir/test.pp`, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := validator.NewChecker(validator.StrictError)
+	v.EnableRule(validator.ValidateDoubleQuotedString)
+	validator.Validate(v, expr)
+	return v.Issues()
+}
+
+func expectJSON(t *testing.T, value interface{}, expected string) {
+	t.Helper()
+	actual, err := json.MarshalIndent(value, ``, `  `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(actual) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
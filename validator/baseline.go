@@ -0,0 +1,213 @@
+package validator
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// A Baseline records known issues so that only issues that are not recorded are reported. An issue is
+// identified by its code, its file, and a fingerprint of the source of the offending expression. Line
+// numbers are not recorded so a baseline remains valid when the source is edited elsewhere. Files are
+// recorded with cleaned, slash separated paths that are relative to the directory of the baseline file,
+// so a baseline remains valid when the validator is run from another directory.
+type Baseline struct {
+	Entries []*BaselineEntry `json:"entries"`
+
+	dir string
+}
+
+// A BaselineEntry records the number of issues with the same code, file, and fingerprint
+type BaselineEntry struct {
+	Code        issue.Code `json:"code"`
+	File        string     `json:"file"`
+	Fingerprint string     `json:"fingerprint"`
+	Count       int        `json:"count"`
+}
+
+type baselineKey struct {
+	code        issue.Code
+	file        string
+	fingerprint string
+}
+
+// sourced is implemented by locations that know their source, such as parser.Expression
+type sourced interface {
+	issue.Location
+	Locator() *parser.Locator
+	ByteOffset() int
+	ByteLength() int
+}
+
+// LoadBaseline reads a baseline from the JSON file at the given path. An empty baseline is returned
+// when the file doesn't exist.
+func LoadBaseline(path string) (*Baseline, error) {
+	b := &Baseline{Entries: []*BaselineEntry{}, dir: filepath.Dir(path)}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return b, nil
+		}
+		return nil, issue.NewReported(ValidateInvalidBaseline, issue.SeverityError, issue.H{`detail`: err.Error()}, issue.NewLocation(path, 0, 0))
+	}
+	if err = json.Unmarshal(content, b); err != nil {
+		return nil, issue.NewReported(ValidateInvalidBaseline, issue.SeverityError, issue.H{`detail`: err.Error()}, issue.NewLocation(path, 0, 0))
+	}
+	return b, nil
+}
+
+// Save writes the receiver as JSON to the file at the given path
+func (b *Baseline) Save(path string) error {
+	content, err := json.MarshalIndent(b, ``, `  `)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// Record replaces the entries for the given file with entries for the given issues. Issues without a
+// location are not recorded.
+func (b *Baseline) Record(file string, issues []issue.Reported) {
+	file = b.relativePath(file)
+	entries := make(map[baselineKey]*BaselineEntry, len(issues))
+	kept := make([]*BaselineEntry, 0, len(b.Entries)+len(issues))
+	for _, entry := range b.Entries {
+		if entry.File != file {
+			kept = append(kept, entry)
+		}
+	}
+	for _, ri := range issues {
+		key, ok := b.keyOf(ri)
+		if !ok || key.file != file {
+			continue
+		}
+		if entry, ok := entries[key]; ok {
+			entry.Count++
+			continue
+		}
+		entry := &BaselineEntry{Code: key.code, File: key.file, Fingerprint: key.fingerprint, Count: 1}
+		entries[key] = entry
+		kept = append(kept, entry)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		ei, ej := kept[i], kept[j]
+		if ei.File != ej.File {
+			return ei.File < ej.File
+		}
+		if ei.Code != ej.Code {
+			return ei.Code < ej.Code
+		}
+		return ei.Fingerprint < ej.Fingerprint
+	})
+	b.Entries = kept
+}
+
+// Check returns the given issues that are not recorded in the receiver followed by one
+// ValidateBaselineEntryGone warning for each entry for the given file that no longer
+// matches an issue.
+func (b *Baseline) Check(file string, issues []issue.Reported) []issue.Reported {
+	relFile := b.relativePath(file)
+	remaining := make(map[baselineKey]int, len(b.Entries))
+	for _, entry := range b.Entries {
+		if entry.File == relFile {
+			remaining[baselineKey{entry.Code, entry.File, entry.Fingerprint}] += entry.Count
+		}
+	}
+
+	result := make([]issue.Reported, 0, len(issues))
+	for _, ri := range issues {
+		if key, ok := b.keyOf(ri); ok && remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		result = append(result, ri)
+	}
+
+	for _, entry := range b.Entries {
+		key := baselineKey{entry.Code, entry.File, entry.Fingerprint}
+		if remaining[key] > 0 {
+			// Report each entry once even if it is listed more than once
+			remaining[key] = 0
+			result = append(result, issue.NewReported(ValidateBaselineEntryGone, issue.SeverityWarning,
+				issue.H{`code`: entry.Code, `fingerprint`: entry.Fingerprint}, issue.NewLocation(file, 0, 0)))
+		}
+	}
+	return result
+}
+
+func (b *Baseline) keyOf(ri issue.Reported) (baselineKey, bool) {
+	loc := ri.Location()
+	if loc == nil {
+		return baselineKey{}, false
+	}
+	return baselineKey{ri.Code(), b.relativePath(loc.File()), Fingerprint(ri)}, true
+}
+
+// relativePath returns the cleaned and slash separated path of the given file relative to the directory
+// of the baseline file. The path is only cleaned when the receiver wasn't loaded from a file or when no
+// relative path can be determined.
+func (b *Baseline) relativePath(file string) string {
+	file = filepath.Clean(file)
+	if b.dir != `` {
+		if abs, err := filepath.Abs(file); err == nil {
+			if dir, err := filepath.Abs(b.dir); err == nil {
+				if rel, err := filepath.Rel(dir, abs); err == nil {
+					file = rel
+				}
+			}
+		}
+	}
+	return filepath.ToSlash(file)
+}
+
+// Fingerprint returns a fingerprint of the given issue that is computed from the source of the offending
+// expression with all whitespace collapsed. The source line is used when the expression consists only of
+// whitespace. The arguments of the issue are used when its location has no known source.
+func Fingerprint(ri issue.Reported) string {
+	h := sha1.New()
+	h.Write([]byte(ri.Code()))
+	h.Write([]byte{0})
+	if loc, ok := ri.Location().(sourced); ok {
+		source := loc.Locator().String()
+		start := loc.ByteOffset()
+		end := start + loc.ByteLength()
+		text := normalizeWhitespace(source[start:end])
+		if text == `` {
+			if ix := strings.LastIndexByte(source[:start], '\n'); ix >= 0 {
+				start = ix + 1
+			} else {
+				start = 0
+			}
+			if ix := strings.IndexByte(source[end:], '\n'); ix >= 0 {
+				end += ix
+			} else {
+				end = len(source)
+			}
+			text = normalizeWhitespace(source[start:end])
+		}
+		h.Write([]byte(text))
+	} else {
+		keys := append([]string{}, ri.Keys()...)
+		sort.Strings(keys)
+		b := bytes.NewBufferString(``)
+		for _, key := range keys {
+			fmt.Fprintf(b, "%s=%v\n", key, ri.Argument(key))
+		}
+		h.Write(b.Bytes())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func normalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), ` `)
+}
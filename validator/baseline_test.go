@@ -0,0 +1,107 @@
+package validator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestBaselineIgnoresLineNumbers(t *testing.T) {
+	b := &Baseline{}
+	b.Record(`a.pp`, validateFile(t, `a.pp`, "notice(\"a\")\nnotice('b')\n"))
+	if len(b.Entries) != 1 || b.Entries[0].Code != ValidateDoubleQuotedString || b.Entries[0].Count != 1 {
+		t.Fatalf(`unexpected entries %v`, b.Entries)
+	}
+
+	issues := b.Check(`a.pp`, validateFile(t, `a.pp`, "# Added line\nnotice('b')\nnotice(  \"a\" )\n"))
+	expectIssueCodes(t, issues)
+
+	issues = b.Check(`a.pp`, validateFile(t, `a.pp`, "notice(\"a\")\nnotice(\"b\")\n"))
+	expectIssueCodes(t, issues, ValidateDoubleQuotedString)
+	if line := issues[0].Location().Line(); line != 2 {
+		t.Errorf(`expected new issue on line 2, got line %d`, line)
+	}
+}
+
+func TestBaselineCounts(t *testing.T) {
+	b := &Baseline{}
+	b.Record(`a.pp`, validateFile(t, `a.pp`, "notice(\"a\")\nnotice(\"a\")\n"))
+	if len(b.Entries) != 1 || b.Entries[0].Count != 2 {
+		t.Fatalf(`unexpected entries %v`, b.Entries)
+	}
+	expectIssueCodes(t, b.Check(`a.pp`, validateFile(t, `a.pp`, "notice(\"a\")\nnotice(\"a\")\nnotice(\"a\")\n")),
+		ValidateDoubleQuotedString)
+}
+
+func TestBaselineEntryGone(t *testing.T) {
+	b := &Baseline{}
+	b.Record(`a.pp`, validateFile(t, `a.pp`, "notice(\"a\")\n"))
+	b.Record(`b.pp`, validateFile(t, `b.pp`, "notice(\"b\")\n"))
+
+	issues := b.Check(`a.pp`, validateFile(t, `a.pp`, "notice('a')\n"))
+	expectIssueCodes(t, issues, ValidateBaselineEntryGone)
+	if issues[0].Location().File() != `a.pp` {
+		t.Errorf(`expected issue for a.pp, got %s`, issues[0])
+	}
+}
+
+func TestBaselineSaveAndLoad(t *testing.T) {
+	dir, err := ioutil.TempDir(``, `baseline`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, `baseline.json`)
+	a := filepath.Join(dir, `a.pp`)
+	b := filepath.Join(dir, `manifests`, `b.pp`)
+	bl, err := LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bl.Record(b, validateFile(t, b, "notice(\"b\")\n"))
+	bl.Record(a, validateFile(t, a, "notice(\"a\")\n"))
+	if err = bl.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	bl, err = LoadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bl.Entries) != 2 || bl.Entries[0].File != `a.pp` || bl.Entries[1].File != `manifests/b.pp` {
+		t.Fatalf(`unexpected entries %v`, bl.Entries)
+	}
+	expectIssueCodes(t, bl.Check(b, validateFile(t, b, "notice(\"b\")\n")))
+
+	// The same file given with a path that isn't clean
+	other := strings.Join([]string{dir, `manifests`, `..`, `.`, `manifests`, `b.pp`}, string(filepath.Separator))
+	expectIssueCodes(t, bl.Check(other, validateFile(t, other, "notice(\"b\")\n")))
+
+	issues := bl.Check(a, validateFile(t, a, "notice('a')\n"))
+	expectIssueCodes(t, issues, ValidateBaselineEntryGone)
+	if issues[0].Location().File() != a {
+		t.Errorf(`expected issue for %s, got %s`, a, issues[0])
+	}
+
+	writeFile(t, path, `{ "entries": 3 }`)
+	_, err = LoadBaseline(path)
+	expectError(t, err, ValidateInvalidBaseline)
+}
+
+func validateFile(t *testing.T, file, source string) []issue.Reported {
+	t.Helper()
+	expr, err := parser.CreateParser().Parse(file, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewChecker(StrictError)
+	v.EnableRule(ValidateDoubleQuotedString)
+	Validate(v, expr)
+	return v.Issues()
+}
@@ -83,6 +83,7 @@ type Checker interface {
 
 	checkStepExpression(e *parser.StepExpression)
 	checkApplication(e *parser.Application)
+	checkApplyExpression(e *parser.ApplyExpression)
 	checkAssignmentExpression(e *parser.AssignmentExpression)
 	checkAttributeOperation(e *parser.AttributeOperation)
 	checkAttributesOperation(e *parser.AttributesOperation)
@@ -134,6 +135,8 @@ func Check(v Checker, e parser.Expression) {
 		v.checkStepExpression(e)
 	case *parser.Application:
 		v.checkApplication(e)
+	case *parser.ApplyExpression:
+		v.checkApplyExpression(e)
 	case *parser.AssignmentExpression:
 		v.checkAssignmentExpression(e)
 	case *parser.AttributeOperation:
@@ -217,9 +220,8 @@ func (v *basicChecker) Validate(e parser.Expression) {
 
 func (v *basicChecker) initialize(strict Strictness) {
 	v.severities = make(map[issue.Code]issue.Severity, 5)
-	v.Demote(ValidateFutureReservedWord, issue.SeverityDeprecation)
-	v.Demote(ValidateDuplicateKey, issue.Severity(strict))
-	v.Demote(ValidateIdemExpressionNotLast, issue.Severity(strict))
+	v.mustDemote(ValidateFutureReservedWord, issue.SeverityDeprecation)
+	SetStrictness(v, strict)
 }
 
 func (v *basicChecker) illegalWorkflowOperation(e parser.Expression) {
@@ -242,6 +244,39 @@ func (v *basicChecker) checkAssignmentExpression(e *parser.AssignmentExpression)
 func (v *basicChecker) checkApplication(e *parser.Application) {
 }
 
+// checkApplyExpression checks that the apply block is in a plan and that it is given the targets and an
+// optional hash of options
+func (v *basicChecker) checkApplyExpression(e *parser.ApplyExpression) {
+	if !v.inPlan() {
+		v.Accept(ValidateApplyOutsidePlan, e, issue.H{})
+	}
+	if n := len(e.Arguments()); n < 1 || n > 2 {
+		v.Accept(ValidateApplyArguments, e, issue.H{`count`: n})
+	}
+}
+
+// inPlan returns true if the currently validated expression is in a plan. The path contains the embedded
+// FunctionDefinition of the plan since PlanDefinition inherits its AllContents method.
+func (v *basicChecker) inPlan() bool {
+	if len(v.path) == 0 {
+		return false
+	}
+	program, ok := v.path[0].(*parser.Program)
+	if !ok {
+		return false
+	}
+	for _, d := range program.Definitions() {
+		if plan, ok := d.(*parser.PlanDefinition); ok {
+			for _, p := range v.path {
+				if p == &plan.FunctionDefinition {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 func (v *basicChecker) checkAttributeOperation(e *parser.AttributeOperation) {
 	if e.Operator() == `+>` {
 		p := v.Container()
@@ -791,7 +791,7 @@ func parseAndValidate(t *testing.T, str string, parserOptions ...parser.Option)
 func parse(t *testing.T, str string, parserOptions ...parser.Option) *parser.Program {
 	expr, err := parser.CreateParser(parserOptions...).Parse(``, str, false)
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Error(err.Error())
 		return nil
 	}
 	block, ok := expr.(*parser.Program)
@@ -0,0 +1,173 @@
+package validator
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigFileNames are the names of the configuration files that FindConfig searches for, in order of precedence
+var ConfigFileNames = []string{`.puppet-parser.yaml`, `.puppet-parser.yml`, `.puppet-parser.json`}
+
+// A Config controls the severities of issues and the rules that a validator applies. It can be read from a
+// YAML or JSON file:
+//
+//	enable:
+//	  - style
+//	  - VALIDATE_EXEC_NOT_IDEMPOTENT
+//	disable:
+//	  - VALIDATE_LINE_TOO_LONG
+//	severities:
+//	  VALIDATE_DUPLICATE_KEY: warning
+//	  VALIDATE_HARD_TAB: ignore
+//
+// The enable and disable lists contain names of rule packs or issue codes of rules.
+type Config struct {
+	Enable     []string          `json:"enable,omitempty" yaml:"enable,omitempty"`
+	Disable    []string          `json:"disable,omitempty" yaml:"disable,omitempty"`
+	Severities map[string]string `json:"severities,omitempty" yaml:"severities,omitempty"`
+
+	location issue.Location
+}
+
+// FindConfig searches the given directory and its parent directories for a configuration file and
+// returns the path of the first file found.
+func FindConfig(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ``, false
+	}
+	for {
+		for _, name := range ConfigFileNames {
+			path := filepath.Join(dir, name)
+			if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+				return path, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ``, false
+		}
+		dir = parent
+	}
+}
+
+// LoadConfig reads the configuration file at the given path. Files with the extension ".json" are read
+// as JSON and all other files as YAML. Unknown keys are errors in both formats.
+func LoadConfig(path string) (*Config, error) {
+	location := issue.NewLocation(path, 0, 0)
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, issue.NewReported(ValidateInvalidConfig, issue.SeverityError, issue.H{`detail`: err.Error()}, location)
+	}
+	c := &Config{location: location}
+	if strings.HasSuffix(path, `.json`) {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(c)
+	} else {
+		err = yaml.UnmarshalStrict(content, c)
+	}
+	if err != nil {
+		return nil, issue.NewReported(ValidateInvalidConfig, issue.SeverityError, issue.H{`detail`: err.Error()}, location)
+	}
+	return c, nil
+}
+
+// ParseSeverity returns the severity with the given name. The name "off" is accepted as an alias for "ignore".
+func ParseSeverity(name string) (issue.Severity, error) {
+	switch strings.ToLower(name) {
+	case `ignore`, `off`:
+		return issue.SeverityIgnore, nil
+	case `deprecation`:
+		return issue.SeverityDeprecation, nil
+	case `warning`:
+		return issue.SeverityWarning, nil
+	case `error`:
+		return issue.SeverityError, nil
+	default:
+		return 0, issue.NewReported(ValidateInvalidSeverity, issue.SeverityError, issue.H{`severity`: name}, noLocation)
+	}
+}
+
+// Apply enables and disables the rules of the receiver in the given validator and then changes the
+// severities. The first problem found, such as an unknown rule or an attempt to demote a hard
+// issue, is returned as an error.
+func (c *Config) Apply(v Validator) error {
+	for _, name := range c.Enable {
+		codes, err := c.ruleCodes(name)
+		if err != nil {
+			return err
+		}
+		for _, code := range codes {
+			v.EnableRule(code)
+		}
+	}
+	for _, name := range c.Disable {
+		codes, err := c.ruleCodes(name)
+		if err != nil {
+			return err
+		}
+		for _, code := range codes {
+			v.DisableRule(code)
+		}
+	}
+
+	// Sort to make the error deterministic
+	codes := make([]string, 0, len(c.Severities))
+	for code := range c.Severities {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		severity, err := ParseSeverity(c.Severities[code])
+		if err == nil {
+			err = v.Demote(issue.Code(code), severity)
+		}
+		if err != nil {
+			return c.locate(err)
+		}
+	}
+	return nil
+}
+
+// ruleCodes returns the codes of the rules in the rule pack with the given name or, if no such pack
+// exists, the code of the rule with the given name.
+func (c *Config) ruleCodes(name string) ([]issue.Code, error) {
+	if pack := RulePack(name); len(pack) > 0 {
+		codes := make([]issue.Code, len(pack))
+		for i, rule := range pack {
+			codes[i] = rule.Code
+		}
+		return codes, nil
+	}
+	if _, ok := RuleForCode(issue.Code(name)); ok {
+		return []issue.Code{issue.Code(name)}, nil
+	}
+	names := make([]string, 0)
+	packs := make(map[string]bool)
+	for _, rule := range Rules() {
+		if rule.Pack != `` && !packs[rule.Pack] {
+			packs[rule.Pack] = true
+			names = append(names, rule.Pack)
+		}
+		names = append(names, string(rule.Code))
+	}
+	return nil, c.locate(issue.NewReported(ValidateUnknownRule, issue.SeverityError,
+		issue.H{`name`: name, `suggestion`: parser.Suggestion(name, names)}, noLocation))
+}
+
+func (c *Config) locate(err error) error {
+	if ri, ok := err.(issue.Reported); ok && c.location != nil {
+		return ri.WithLocation(c.location)
+	}
+	return err
+}
@@ -0,0 +1,174 @@
+package validator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestDemoteHardIssue(t *testing.T) {
+	v := NewChecker(StrictError)
+	expectError(t, v.Demote(ValidateNotRvalue, issue.SeverityWarning), ValidateNotDemotable)
+	expectError(t, v.Demote(`NO_SUCH_ISSUE`, issue.SeverityWarning), ValidateUnknownIssue)
+	expectError(t, v.Demote(ValidateDuplicateKey, issue.Severity(17)), ValidateInvalidSeverity)
+	if err := v.Demote(ValidateDuplicateKey, issue.SeverityWarning); err != nil {
+		t.Error(err)
+	}
+	if err := v.Demote(ValidateNotRvalue, issue.SeverityError); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInvalidStrictness(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error(`expected a panic for an invalid strictness`)
+		} else if ri, ok := r.(issue.Reported); !ok || ri.Code() != ValidateInvalidSeverity {
+			t.Errorf(`expected %s, got %v`, ValidateInvalidSeverity, r)
+		}
+	}()
+	NewChecker(Strictness(17))
+}
+
+func TestFindAndLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir(``, `config`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sub := filepath.Join(dir, `a`, `b`)
+	if err = os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := FindConfig(sub); ok {
+		t.Skip(`a configuration file exists in a parent of the temporary directory`)
+	}
+
+	path := filepath.Join(dir, `a`, `.puppet-parser.yaml`)
+	writeFile(t, path, "enable:\n  - style\ndisable:\n  - VALIDATE_HARD_TAB\nseverities:\n  VALIDATE_DUPLICATE_KEY: ignore\n  VALIDATE_TRAILING_WHITESPACE: error\n")
+	found, ok := FindConfig(sub)
+	if !ok || found != path {
+		t.Fatalf(`expected to find %s, got '%s'`, path, found)
+	}
+
+	c, err := LoadConfig(found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewChecker(StrictError)
+	if err = c.Apply(v); err != nil {
+		t.Fatal(err)
+	}
+	issues := validateWith(t, v, "$x = {a => 1, a => 2}\t \n")
+	expectIssueCodes(t, issues, ValidateTrailingWhitespace)
+	if issues[0].Severity() != issue.SeverityError {
+		t.Errorf(`expected severity error, got %s`, issues[0].Severity())
+	}
+}
+
+func TestLoadJSONConfig(t *testing.T) {
+	dir, err := ioutil.TempDir(``, `config`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, `.puppet-parser.json`)
+	writeFile(t, path, `{"enable": ["best_practice"], "severities": {"VALIDATE_EXEC_NOT_IDEMPOTENT": "error"}}`)
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := NewChecker(StrictError)
+	if err = c.Apply(v); err != nil {
+		t.Fatal(err)
+	}
+	issues := validateWith(t, v, `exec { 'make': }`)
+	expectIssueCodes(t, issues, ValidateExecNotIdempotent)
+	if issues[0].Severity() != issue.SeverityError {
+		t.Errorf(`expected severity error, got %s`, issues[0].Severity())
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	expectConfigError(t, `enable: [no_such_pack]`, ValidateUnknownRule)
+	expectConfigError(t, `disable: [VALIDATE_NOT_RVALUE]`, ValidateUnknownRule)
+	expectConfigError(t, `severities: {VALIDATE_DUPLICATE_KEY: fatal}`, ValidateInvalidSeverity)
+	expectConfigError(t, `severities: {VALIDATE_NOT_RVALUE: warning}`, ValidateNotDemotable)
+	expectConfigError(t, `enabled: [style]`, ValidateInvalidConfig)
+	expectConfigFileError(t, `.puppet-parser.json`, `{"enabled": ["style"]}`, ValidateInvalidConfig)
+}
+
+func TestConfigSuggestion(t *testing.T) {
+	err := (&Config{Enable: []string{`best_practise`}}).Apply(NewChecker(StrictError))
+	expectError(t, err, ValidateUnknownRule)
+	if err != nil && !strings.Contains(err.Error(), `Did you mean 'best_practice'?`) {
+		t.Errorf(`expected a suggestion, got %s`, err.Error())
+	}
+}
+
+func TestConfigHardIssueAsError(t *testing.T) {
+	dir, err := ioutil.TempDir(``, `config`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, `.puppet-parser.yaml`)
+	writeFile(t, path, `severities: {VALIDATE_NOT_RVALUE: error}`)
+	c, err := LoadConfig(path)
+	if err == nil {
+		err = c.Apply(NewChecker(StrictError))
+	}
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func expectConfigError(t *testing.T, content string, code issue.Code) {
+	t.Helper()
+	expectConfigFileError(t, `.puppet-parser.yaml`, content, code)
+}
+
+func expectConfigFileError(t *testing.T, name, content string, code issue.Code) {
+	t.Helper()
+	dir, err := ioutil.TempDir(``, `config`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, name)
+	writeFile(t, path, content)
+	c, err := LoadConfig(path)
+	if err == nil {
+		err = c.Apply(NewChecker(StrictError))
+	}
+	expectError(t, err, code)
+	if ri, ok := err.(issue.Reported); ok && ri.Location().File() != path {
+		t.Errorf(`expected error location %s, got %s`, path, ri.Location().File())
+	}
+}
+
+func expectError(t *testing.T, err error, code issue.Code) {
+	t.Helper()
+	if err == nil {
+		t.Errorf(`expected %s, got no error`, code)
+		return
+	}
+	if ri, ok := err.(issue.Reported); !ok || ri.Code() != code {
+		t.Errorf(`expected %s, got %s`, code, err.Error())
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
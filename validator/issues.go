@@ -2,24 +2,42 @@ package validator
 
 import (
 	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
 )
 
 const (
 	ValidateAppendsDeletesNoLongerSupported = `VALIDATE_APPENDS_DELETES_NO_LONGER_SUPPORTED`
+	ValidateApplyArguments                  = `VALIDATE_APPLY_ARGUMENTS`
+	ValidateApplyOutsidePlan                = `VALIDATE_APPLY_OUTSIDE_PLAN`
+	ValidateArrowNotAligned                 = `VALIDATE_ARROW_NOT_ALIGNED`
+	ValidateBaselineEntryGone               = `VALIDATE_BASELINE_ENTRY_GONE`
+	ValidateCallStyleMismatch               = `VALIDATE_CALL_STYLE_MISMATCH`
+	ValidateCallTypeMismatch                = `VALIDATE_CALL_TYPE_MISMATCH`
 	ValidateCapturesRestNotLast             = `VALIDATE_CAPTURES_REST_NOT_LAST`
 	ValidateCapturesRestNotSupported        = `VALIDATE_CAPTURES_REST_NOT_SUPPORTED`
 	ValidateCatalogOperationNotSupported    = `VALIDATE_CATALOG_OPERATION_NOT_SUPPORTED`
 	ValidateCrossScopeAssignment            = `VALIDATE_CROSS_SCOPE_ASSIGNMENT`
+	ValidateDoubleQuotedString              = `VALIDATE_DOUBLE_QUOTED_STRING`
 	ValidateDuplicateDefault                = `VALIDATE_DUPLICATE_DEFAULT`
+	ValidateDuplicateIterationVariable      = `VALIDATE_DUPLICATE_ITERATION_VARIABLE`
 	ValidateDuplicateKey                    = `VALIDATE_DUPLICATE_KEY`
 	ValidateDuplicateParameter              = `VALIDATE_DUPLICATE_PARAMETER`
+	ValidateDuplicateReturn                 = `VALIDATE_DUPLICATE_RETURN`
+	ValidateEnsureNotFirst                  = `VALIDATE_ENSURE_NOT_FIRST`
+	ValidateExecCommandInterpolation        = `VALIDATE_EXEC_COMMAND_INTERPOLATION`
+	ValidateExecNotIdempotent               = `VALIDATE_EXEC_NOT_IDEMPOTENT`
 	ValidateFutureReservedWord              = `VALIDATE_FUTURE_RESERVED_WORD`
+	ValidateHardTab                         = `VALIDATE_HARD_TAB`
 	ValidateIdemExpressionNotLast           = `VALIDATE_IDEM_EXPRESSION_NOT_LAST`
 	ValidateIdemNotAllowedLast              = `VALIDATE_IDEM_NOT_ALLOWED_LAST`
 	ValidateIllegalAssignmentContext        = `VALIDATE_ILLEGAL_ASSIGNMENT_CONTEXT`
 	ValidateIllegalAssignmentViaIndex       = `VALIDATE_ILLEGAL_ASSIGNMENT_VIA_INDEX`
 	ValidateIllegalAttributeAppend          = `VALIDATE_ILLEGAL_ATTRIBUTE_APPEND`
+	ValidateIllegalCall                     = `VALIDATE_ILLEGAL_CALL`
 	ValidateIllegalClassref                 = `VALIDATE_ILLEGAL_CLASSREF`
+	ValidateIllegalDeclarationAlias         = `VALIDATE_ILLEGAL_DECLARATION_ALIAS`
+	ValidateIllegalDeclarationKey           = `VALIDATE_ILLEGAL_DECLARATION_KEY`
+	ValidateIllegalDeclarationName          = `VALIDATE_ILLEGAL_DECLARATION_NAME`
 	ValidateIllegalDefinitionName           = `VALIDATE_ILLEGAL_DEFINITION_NAME`
 	ValidateIllegalExpression               = `VALIDATE_ILLEGAL_EXPRESSION`
 	ValidateIllegalHostnameChars            = `VALIDATE_ILLEGAL_HOSTNAME_CHARS`
@@ -29,24 +47,74 @@ const (
 	ValidateIllegalParameterName            = `VALIDATE_ILLEGAL_PARAMETER_NAME`
 	ValidateIllegalQueryExpression          = `VALIDATE_ILLEGAL_QUERY_EXPRESSION`
 	ValidateIllegalRegexpTypeMapping        = `VALIDATE_ILLEGAL_REGEXP_TYPE_MAPPING`
+	ValidateIllegalReturnAlias              = `VALIDATE_ILLEGAL_RETURN_ALIAS`
 	ValidateIllegalSingleTypeMapping        = `VALIDATE_ILLEGAL_SINGLE_TYPE_MAPPING`
+	ValidateInvalidBaseline                 = `VALIDATE_INVALID_BASELINE`
+	ValidateInvalidConfig                   = `VALIDATE_INVALID_CONFIG`
+	ValidateInvalidSeverity                 = `VALIDATE_INVALID_SEVERITY`
 	ValidateInvalidStepStyle                = `VALIDATE_INVALID_STEP_STYLE`
+	ValidateIterationNameClash              = `VALIDATE_ITERATION_NAME_CLASH`
+	ValidateIterationParameterCount         = `VALIDATE_ITERATION_PARAMETER_COUNT`
+	ValidateIterationVariableClash          = `VALIDATE_ITERATION_VARIABLE_CLASH`
+	ValidateIterationVariableCount          = `VALIDATE_ITERATION_VARIABLE_COUNT`
+	ValidateIterationWithoutReturns         = `VALIDATE_ITERATION_WITHOUT_RETURNS`
+	ValidateLineTooLong                     = `VALIDATE_LINE_TOO_LONG`
+	ValidateMissingCallParameter            = `VALIDATE_MISSING_CALL_PARAMETER`
+	ValidateMissingPlanParameter            = `VALIDATE_MISSING_PLAN_PARAMETER`
+	ValidateMixedDeclarations               = `VALIDATE_MIXED_DECLARATIONS`
 	ValidateMultipleAttributesUnfold        = `VALIDATE_MULTIPLE_ATTRIBUTES_UNFOLD`
 	ValidateNotAbsoluteTopLevel             = `VALIDATE_NOT_ABSOLUTE_TOP_LEVEL`
+	ValidateNotDemotable                    = `VALIDATE_NOT_DEMOTABLE`
 	ValidateNotRvalue                       = `VALIDATE_NOT_RVALUE`
 	ValidateNotTopLevel                     = `VALIDATE_NOT_TOP_LEVEL`
 	ValidateNotVirtualizable                = `VALIDATE_NOT_VIRTUALIZABLE`
+	ValidatePackageEnsureLatest             = `VALIDATE_PACKAGE_ENSURE_LATEST`
+	ValidatePlanParameterTypeMismatch       = `VALIDATE_PLAN_PARAMETER_TYPE_MISMATCH`
+	ValidateRecursiveWorkflow               = `VALIDATE_RECURSIVE_WORKFLOW`
 	ValidateReservedParameter               = `VALIDATE_RESERVED_PARAMETER`
 	ValidateReservedTypeName                = `VALIDATE_RESERVED_TYPE_NAME`
 	ValidateReservedWord                    = `VALIDATE_RESERVED_WORD`
+	ValidateReturnNotConsumed               = `VALIDATE_RETURN_NOT_CONSUMED`
+	ValidateReturnProducedTwice             = `VALIDATE_RETURN_PRODUCED_TWICE`
+	ValidateStepCycle                       = `VALIDATE_STEP_CYCLE`
+	ValidateTrailingWhitespace              = `VALIDATE_TRAILING_WHITESPACE`
+	ValidateUnbracedVariable                = `VALIDATE_UNBRACED_VARIABLE`
+	ValidateUndeclaredStateVariable         = `VALIDATE_UNDECLARED_STATE_VARIABLE`
+	ValidateUnknownCallParameter            = `VALIDATE_UNKNOWN_CALL_PARAMETER`
+	ValidateUnknownCallReturn               = `VALIDATE_UNKNOWN_CALL_RETURN`
+	ValidateUnknownIssue                    = `VALIDATE_UNKNOWN_ISSUE`
+	ValidateUnknownPlanParameter            = `VALIDATE_UNKNOWN_PLAN_PARAMETER`
+	ValidateUnknownRule                     = `VALIDATE_UNKNOWN_RULE`
+	ValidateUnquotedFileMode                = `VALIDATE_UNQUOTED_FILE_MODE`
+	ValidateUnreachableStep                 = `VALIDATE_UNREACHABLE_STEP`
+	ValidateUnresolvedStep                  = `VALIDATE_UNRESOLVED_STEP`
+	ValidateUnsatisfiedParameter            = `VALIDATE_UNSATISFIED_PARAMETER`
+	ValidateUnsatisfiedReturn               = `VALIDATE_UNSATISFIED_RETURN`
 	ValidateUnsupportedExpression           = `VALIDATE_UNSUPPORTED_EXPRESSION`
 	ValidateUnsupportedOperatorInContext    = `VALIDATE_UNSUPPORTED_OPERATOR_IN_CONTEXT`
+	ValidateUnusedSuppression               = `VALIDATE_UNUSED_SUPPRESSION`
 	ValidateWorkflowOperationNotSupported   = `VALIDATE_WORKFLOW_OPERATION_NOT_SUPPORTED`
+	ValidateWorldWritableFileMode           = `VALIDATE_WORLD_WRITABLE_FILE_MODE`
 )
 
+// Formats the 'suggestion' argument of issues about unknown names
+var suggestionFormat = issue.HF{`suggestion`: parser.DidYouMean}
+
 func init() {
 	issue.Hard(ValidateAppendsDeletesNoLongerSupported, `The operator '%{operator}' is no longer supported. See http://links.puppet.com/remove-plus-equals`)
 
+	issue.Hard(ValidateApplyArguments, `An apply block takes the targets and an optional hash of options. Got %{count} arguments`)
+
+	issue.Hard(ValidateApplyOutsidePlan, `An apply block can only be used in a plan`)
+
+	issue.Soft(ValidateArrowNotAligned, `The arrow should be at column %{expected} to align with the other arrows of the resource body`)
+
+	issue.Soft(ValidateBaselineEntryGone, `The baseline entry for %{code} with fingerprint %{fingerprint} no longer matches an issue. Write the baseline again to remove it`)
+
+	issue.Hard(ValidateCallStyleMismatch, `The %{style} '%{step}' calls the %{target_style} '%{target}'. A step can only call a step of the same style`)
+
+	issue.Hard(ValidateCallTypeMismatch, `The %{kind} '%{name}' of %{style} '%{step}' is declared as %{type} but the called %{target_style} '%{target}' declares it as %{target_type}`)
+
 	issue.Hard(ValidateCapturesRestNotLast, `Parameter $%{param} is not last, and has 'captures rest'`)
 
 	issue.Hard2(ValidateCapturesRestNotSupported,
@@ -57,16 +125,30 @@ func init() {
 
 	issue.Hard(ValidateCrossScopeAssignment, `Illegal attempt to assign to '%{name}'. Cannot assign to variables in other namespaces`)
 
+	issue.Soft(ValidateDoubleQuotedString, `Double quoted string without interpolation. Use single quotes for literal strings`)
+
 	issue.Hard2(ValidateDuplicateDefault,
 		`This %{container} already has a 'default' entry - this is a duplicate`,
 		issue.HF{`container`: issue.Label})
 
+	issue.Hard(ValidateDuplicateIterationVariable, `The iteration variable '$%{name}' of %{style} '%{step}' is declared more than once`)
+
 	issue.Soft(ValidateDuplicateKey, `The key '%{key}' is declared more than once`)
 
 	issue.Hard(ValidateDuplicateParameter, `The parameter '%{param}' is declared more than once in the parameter list`)
 
+	issue.Hard(ValidateDuplicateReturn, `The return value '%{name}' is declared more than once in %{style} '%{step}'`)
+
+	issue.Soft(ValidateEnsureNotFirst, `The 'ensure' attribute should be the first attribute of the resource body`)
+
+	issue.Soft(ValidateExecCommandInterpolation, `The command of exec %{title} interpolates '%{expression}' into a shell string. Unless the value is trusted, this allows command injection. Pass the command as an array or validate the value`)
+
+	issue.Soft(ValidateExecNotIdempotent, `The exec %{title} has none of the attributes 'creates', 'onlyif', 'unless', or 'refreshonly' and will run every time the catalog is applied`)
+
 	issue.Soft(ValidateFutureReservedWord, `Use of future reserved word: '%{word}'`)
 
+	issue.Soft(ValidateHardTab, `Tab character found. Use spaces for indentation and alignment`)
+
 	issue.Soft2(ValidateIdemExpressionNotLast,
 		`This %{expression} has no effect. A value was produced and then forgotten (one or more preceding expressions may have the wrong form)`,
 		issue.HF{`expression`: issue.Label})
@@ -83,8 +165,16 @@ func init() {
 		`Illegal +> operation on attribute %{attr}. This operator can not be used in %{expression}`,
 		issue.HF{`expression`: issue.AnOrA})
 
+	issue.Hard(ValidateIllegalCall, `The 'call' property of %{style} '%{step}' must be the qualified name of a step`)
+
 	issue.Hard(ValidateIllegalClassref, `Illegal type reference. The given name '%{name}' does not conform to the naming rule`)
 
+	issue.Hard(ValidateIllegalDeclarationAlias, `The 'alias' in a declaration of returns of %{style} '%{step}' must be an attribute name or a list of attribute names`)
+
+	issue.Hard(ValidateIllegalDeclarationKey, `'%{key}' is not a valid key in a declaration of %{property} of %{style} '%{step}'. Expected one of 'name', 'type', or '%{value_key}'`)
+
+	issue.Hard(ValidateIllegalDeclarationName, `A declaration of %{property} of %{style} '%{step}' must have a 'name' that is a string`)
+
 	issue.Hard2(ValidateIllegalDefinitionName,
 		`Unacceptable name. The name '%{name}' is unacceptable as the name of %{value}`,
 		issue.HF{`value`: issue.AnOrA})
@@ -112,18 +202,48 @@ func init() {
 		`Illegal type mapping. Expected a Tuple[Regexp,String] on the left side, got %{expression}`,
 		issue.HF{`expression`: issue.AnOrA})
 
+	issue.Hard(ValidateIllegalReturnAlias, `The return value '%{name}' of %{style} '%{step}' cannot be an alias. Only a resource has attributes to alias`)
+
 	issue.Hard2(ValidateIllegalSingleTypeMapping,
 		`Illegal type mapping. Expected a Type on the left side, got %{expression}`,
 		issue.HF{`expression`: issue.AnOrA})
 
+	issue.Hard(ValidateInvalidBaseline, `Unable to read the baseline: %{detail}`)
+
+	issue.Hard(ValidateInvalidConfig, `Unable to read the configuration: %{detail}`)
+
+	issue.Hard(ValidateInvalidSeverity, `Invalid severity '%{severity}'. Expected one of 'ignore', 'deprecation', 'warning', or 'error'`)
+
 	issue.Hard(ValidateInvalidStepStyle, `Expected one of 'for', 'function', 'guard', 'resource', or 'workflow'. Got '%{style}'`)
 
+	issue.Hard(ValidateIterationNameClash, `The iteration of %{style} '%{step}' exposes its returns as '$%{name}' which clashes with a %{kind} of the %{style}`)
+
+	issue.Hard(ValidateIterationParameterCount, `The '%{function}' iteration of %{style} '%{step}' takes %{expected}. Got %{count}`)
+
+	issue.Hard(ValidateIterationVariableClash, `The iteration variable '$%{name}' of %{style} '%{step}' clashes with a parameter of the %{style}`)
+
+	issue.Hard(ValidateIterationVariableCount, `The '%{function}' iteration of %{style} '%{step}' declares %{expected}. Got %{count}`)
+
+	issue.Hard(ValidateIterationWithoutReturns, `The iteration of %{style} '%{step}' is assigned to '$%{name}' but the %{style} has no returns to expose`)
+
+	issue.Soft(ValidateLineTooLong, `The line has %{length} characters which is more than the maximum of %{max}`)
+
+	issue.Hard(ValidateMissingCallParameter, `The %{style} '%{step}' does not declare the parameter '%{name}' that the called %{target_style} '%{target}' requires`)
+
+	issue.Hard(ValidateMissingPlanParameter, `The plan '%{plan}' requires the parameter '%{name}'`)
+
+	issue.Hard2(ValidateMixedDeclarations,
+		`The %{property} of %{style} '%{step}' must be declared in the condensed form or as a list of hashes. Got %{value}`,
+		issue.HF{`value`: issue.AnOrA})
+
 	issue.Hard(ValidateMultipleAttributesUnfold, `Unfolding of attributes from Hash can only be used once per resource body`)
 
 	issue.Hard2(ValidateNotAbsoluteTopLevel,
 		`%{value} may only appear at top level`,
 		issue.HF{`value`: issue.UcAnOrA})
 
+	issue.Hard(ValidateNotDemotable, `Attempt to demote the hard issue '%{code}' to %{severity}`)
+
 	issue.Hard(ValidateNotTopLevel, `Classes, definitions, and nodes may only appear at top level or inside other classes`)
 
 	issue.Hard2(ValidateNotRvalue,
@@ -132,6 +252,12 @@ func init() {
 
 	issue.Hard(ValidateNotVirtualizable, `Resource Defaults/Overrides are not virtualizable`)
 
+	issue.Soft(ValidatePackageEnsureLatest, `The package %{title} has ensure => latest which upgrades it whenever a new version is published. Use 'installed' or a specific version`)
+
+	issue.Hard(ValidatePlanParameterTypeMismatch, `The parameter '%{name}' of plan '%{plan}' expects a value of type %{expected}, got %{actual}`)
+
+	issue.Hard(ValidateRecursiveWorkflow, `The %{style} '%{step}' calls '%{target}' which recursively includes the workflow '%{workflow}'`)
+
 	issue.Hard2(ValidateReservedParameter,
 		`The parameter $%{param} redefines a built in parameter in %{container}`,
 		issue.HF{`container`: issue.AnOrA})
@@ -142,6 +268,48 @@ func init() {
 
 	issue.Hard(ValidateReservedWord, `Use of reserved word: %{word}, must be quoted if intended to be a String value`)
 
+	issue.Soft(ValidateReturnNotConsumed, `The return value '%{name}' of %{style} '%{step}' is not consumed by any other step or returned by the enclosing workflow`)
+
+	issue.Hard(ValidateReturnProducedTwice, `The return value '%{name}' of %{style} '%{step}' is also returned by %{other_style} '%{other}'`)
+
+	issue.Hard(ValidateStepCycle, `The steps %{steps} of workflow '%{workflow}' depend on each other in a cycle`)
+
+	issue.Soft(ValidateTrailingWhitespace, `Trailing whitespace found at the end of the line`)
+
+	issue.Soft(ValidateUnbracedVariable, `The variable '$%{name}' is interpolated without enclosing braces. Use '${%{name}}'`)
+
+	issue.Hard(ValidateUndeclaredStateVariable, `The state of resource '%{step}' references $%{name} which is not a parameter of the resource`)
+
+	issue.Hard2(ValidateUnknownCallParameter,
+		`The parameter '%{name}' of %{style} '%{step}' is not a parameter of the called %{target_style} '%{target}'%{suggestion}`,
+		suggestionFormat)
+
+	issue.Hard2(ValidateUnknownCallReturn,
+		`The return value '%{name}' of %{style} '%{step}' is not returned by the called %{target_style} '%{target}'%{suggestion}`,
+		suggestionFormat)
+
+	issue.Hard(ValidateUnknownIssue, `There is no issue with the code '%{code}'`)
+
+	issue.Hard2(ValidateUnknownPlanParameter,
+		`The plan '%{plan}' has no parameter named '%{name}'%{suggestion}`,
+		suggestionFormat)
+
+	issue.Hard2(ValidateUnknownRule,
+		`There is no rule pack or rule named '%{name}'%{suggestion}`,
+		suggestionFormat)
+
+	issue.Soft(ValidateUnquotedFileMode, `The file mode %{mode} should be a quoted string, e.g. '%{mode}'`)
+
+	issue.Hard(ValidateUnreachableStep, `The %{style} '%{step}' can never run because it depends on a step that can never run`)
+
+	issue.Hard2(ValidateUnresolvedStep,
+		`The %{style} '%{step}' calls '%{name}' which is not declared in the module%{suggestion}`,
+		suggestionFormat)
+
+	issue.Hard(ValidateUnsatisfiedParameter, `The parameter '%{name}' of %{style} '%{step}' is not returned by any other step or declared as a parameter of the enclosing workflow`)
+
+	issue.Hard(ValidateUnsatisfiedReturn, `The return '%{name}' of %{style} '%{step}' is not returned by any of its steps or declared as a parameter of the %{style}`)
+
 	issue.Hard2(ValidateUnsupportedExpression,
 		`Expressions of type %{expression} are not supported in this version of Puppet`,
 		issue.HF{`expression`: issue.AnOrA})
@@ -150,5 +318,9 @@ func init() {
 		`The operator '%{operator}' in %{value} is not supported`,
 		issue.HF{`value`: issue.AnOrA})
 
+	issue.Soft(ValidateUnusedSuppression, `The suppression of %{code} is not used. No such issue was found where it applies`)
+
 	issue.Hard(ValidateWorkflowOperationNotSupported, `The workflow operation '%{operation}' is only available when compiling workflows`)
+
+	issue.Soft(ValidateWorldWritableFileMode, `The file mode %{mode} makes the file writable by all users`)
 }
@@ -0,0 +1,121 @@
+package validator
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// BestPracticePack is the name of the rule pack that checks for risky use of the core resource types.
+// The rules in this pack are not enabled by default.
+const BestPracticePack = `best_practice`
+
+var numericModeExpr = regexp.MustCompile(`\A[0-7]{3,4}\z`)
+
+// Matches symbolic modes such as 'a+w' or 'u=rw,o=rw' that grants write permission to others
+var symbolicWorldWritableExpr = regexp.MustCompile(`(?:\A|,)[ug]*[ao][ugoa]*[+=][rwxXst]*w`)
+
+func init() {
+	packRule(BestPracticePack, ValidateExecCommandInterpolation, checkExecCommand, &parser.ResourceBody{})
+	packRule(BestPracticePack, ValidateExecNotIdempotent, checkExecIdempotent, &parser.ResourceBody{})
+	packRule(BestPracticePack, ValidatePackageEnsureLatest, checkPackageEnsure, &parser.ResourceBody{})
+	packRule(BestPracticePack, ValidateWorldWritableFileMode, checkWorldWritable, &parser.ResourceBody{})
+}
+
+func checkExecCommand(ctx RuleContext, e parser.Expression) {
+	if !isResourceOfType(ctx.Container(), `exec`) {
+		return
+	}
+	body := e.(*parser.ResourceBody)
+	command, ok := attributeValue(body, `command`)
+	if !ok {
+		// The title is the command unless a command is given
+		command = body.Title()
+	}
+	if cs, ok := command.(*parser.ConcatenatedString); ok {
+		for _, segment := range cs.Segments() {
+			if _, ok := segment.(*parser.TextExpression); ok {
+				ctx.Report(segment, issue.H{`title`: body.Title().String(), `expression`: segment.String()})
+			}
+		}
+	}
+}
+
+func checkExecIdempotent(ctx RuleContext, e parser.Expression) {
+	if !isResourceOfType(ctx.Container(), `exec`) {
+		return
+	}
+	body := e.(*parser.ResourceBody)
+	for _, op := range body.Operations() {
+		switch op := op.(type) {
+		case *parser.AttributesOperation:
+			// Attributes given in a hash are unknown
+			return
+		case *parser.AttributeOperation:
+			switch op.Name() {
+			case `creates`, `onlyif`, `unless`, `refreshonly`:
+				return
+			}
+		}
+	}
+	ctx.Report(body, issue.H{`title`: body.Title().String()})
+}
+
+func checkPackageEnsure(ctx RuleContext, e parser.Expression) {
+	if !isResourceOfType(ctx.Container(), `package`) {
+		return
+	}
+	body := e.(*parser.ResourceBody)
+	if ensure, ok := attributeValue(body, `ensure`); ok && literalName(ensure) == `latest` {
+		ctx.Report(ensure, issue.H{`title`: body.Title().String()})
+	}
+}
+
+func checkWorldWritable(ctx RuleContext, e parser.Expression) {
+	if !isResourceOfType(ctx.Container(), `file`) {
+		return
+	}
+	mode, ok := attributeValue(e.(*parser.ResourceBody), `mode`)
+	if !ok {
+		return
+	}
+	var str string
+	switch mode := mode.(type) {
+	case *parser.LiteralString:
+		str = mode.StringValue()
+	case *parser.LiteralInteger:
+		str = mode.String()
+	default:
+		return
+	}
+	if numericModeExpr.MatchString(str) {
+		if bits, err := strconv.ParseInt(str, 8, 32); err == nil && bits&2 != 0 {
+			ctx.Report(mode, issue.H{`mode`: mode.String()})
+		}
+	} else if symbolicWorldWritableExpr.MatchString(str) {
+		ctx.Report(mode, issue.H{`mode`: mode.String()})
+	}
+}
+
+// attributeValue returns the value of the attribute with the given name
+func attributeValue(body *parser.ResourceBody, name string) (parser.Expression, bool) {
+	for _, op := range body.Operations() {
+		if ao, ok := op.(*parser.AttributeOperation); ok && ao.Name() == name {
+			return ao.Value(), true
+		}
+	}
+	return nil, false
+}
+
+// literalName returns the name of a bare word or the value of a literal string
+func literalName(e parser.Expression) string {
+	switch e := e.(type) {
+	case *parser.QualifiedName:
+		return e.Name()
+	case *parser.LiteralString:
+		return e.StringValue()
+	}
+	return ``
+}
@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestExecNotIdempotent(t *testing.T) {
+	issues := validateBestPractice(t, `exec { 'apt-get update': path => '/usr/bin' }`)
+	expectIssueCodes(t, issues, ValidateExecNotIdempotent)
+	if s := issues[0].String(); s != `The exec 'apt-get update' has none of the attributes 'creates', 'onlyif', 'unless', or 'refreshonly' and will run every time the catalog is applied (line: 1, column: 8)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+
+	expectBestPracticeIssues(t, `exec { 'make': creates => '/tmp/out' }`)
+	expectBestPracticeIssues(t, `exec { 'make': refreshonly => true }`)
+	expectBestPracticeIssues(t, `exec { 'make': * => $attrs }`)
+	expectBestPracticeIssues(t, `service { 'make': enable => true }`)
+}
+
+func TestExecCommandInterpolation(t *testing.T) {
+	issues := validateBestPractice(t, `exec { "rm -rf ${dir}": onlyif => 'true' }`)
+	expectIssueCodes(t, issues, ValidateExecCommandInterpolation)
+	if s := issues[0].String(); s != `The command of exec "rm -rf ${dir}" interpolates '${dir}' into a shell string. Unless the value is trusted, this allows command injection. Pass the command as an array or validate the value (line: 1, column: 16)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+
+	expectBestPracticeIssues(t, `exec { 'cleanup': command => "rm -rf $dir $other", onlyif => 'true' }`,
+		ValidateExecCommandInterpolation, ValidateExecCommandInterpolation)
+	expectBestPracticeIssues(t, `exec { 'cleanup': command => ['rm', '-rf', $dir], onlyif => 'true' }`)
+}
+
+func TestPackageEnsureLatest(t *testing.T) {
+	expectBestPracticeIssues(t, `package { 'openssl': ensure => latest }`, ValidatePackageEnsureLatest)
+	expectBestPracticeIssues(t, `package { 'openssl': ensure => 'latest' }`, ValidatePackageEnsureLatest)
+	expectBestPracticeIssues(t, `package { 'openssl': ensure => installed }`)
+	expectBestPracticeIssues(t, `service { 'openssl': ensure => latest }`)
+}
+
+func TestWorldWritableFileMode(t *testing.T) {
+	issues := validateBestPractice(t, `file { '/tmp/x': mode => '0666' }`)
+	expectIssueCodes(t, issues, ValidateWorldWritableFileMode)
+	if s := issues[0].String(); s != `The file mode '0666' makes the file writable by all users (line: 1, column: 26)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+
+	expectBestPracticeIssues(t, `file { '/tmp/x': mode => '777' }`, ValidateWorldWritableFileMode)
+	expectBestPracticeIssues(t, `file { '/tmp/x': mode => 0777 }`, ValidateWorldWritableFileMode)
+	expectBestPracticeIssues(t, `file { '/tmp/x': mode => 'u+rw,o+w' }`, ValidateWorldWritableFileMode)
+	expectBestPracticeIssues(t, `file { '/tmp/x': mode => 'a=rwx' }`, ValidateWorldWritableFileMode)
+	expectBestPracticeIssues(t, `file { '/tmp/x': mode => '0644' }`)
+	expectBestPracticeIssues(t, `file { '/tmp/x': mode => '1775' }`)
+	expectBestPracticeIssues(t, `file { '/tmp/x': mode => 'ug+w' }`)
+}
+
+func expectBestPracticeIssues(t *testing.T, source string, codes ...issue.Code) {
+	t.Helper()
+	expectIssueCodes(t, validateBestPractice(t, source), codes...)
+}
+
+func validateBestPractice(t *testing.T, source string) []issue.Reported {
+	t.Helper()
+	v := NewChecker(StrictError)
+	for _, rule := range RulePack(BestPracticePack) {
+		v.EnableRule(rule.Code)
+	}
+	return validateWith(t, v, source)
+}
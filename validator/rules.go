@@ -0,0 +1,237 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type (
+	// A Rule is a check that can be added to any validator. The rule is applied to all expressions
+	// that are of one of its node types during the same pass over the AST that the validator uses.
+	//
+	// The issue for the Code of a rule must be created (using issue.Hard or issue.Soft) before the
+	// rule is registered. Rules are typically registered from an init() function:
+	//
+	//  func init() {
+	//    issue.Soft(MyNoFooClass, `Classes must not be named 'foo'`)
+	//
+	//    validator.RegisterRule(&validator.Rule{
+	//      Code:      MyNoFooClass,
+	//      Severity:  issue.SeverityWarning,
+	//      Enabled:   true,
+	//      NodeTypes: []reflect.Type{reflect.TypeOf(&parser.HostClassDefinition{})},
+	//      Check: func(ctx validator.RuleContext, e parser.Expression) {
+	//        if e.(*parser.HostClassDefinition).Name() == `foo` {
+	//          ctx.Report(e, issue.NoArgs)
+	//        }
+	//      }})
+	//  }
+	Rule struct {
+		// Code is the code of the issue that the rule reports
+		Code issue.Code
+
+		// Severity is the severity that the rule reports with unless it has been changed using
+		// Demote. The zero value means issue.SeverityError
+		Severity issue.Severity
+
+		// Enabled controls if the rule is enabled in new validators. A rule that isn't enabled must
+		// be enabled using EnableRule before it is applied
+		Enabled bool
+
+		// Pack is the name of the rule pack that the rule belongs to, if any
+		Pack string
+
+		// NodeTypes are the types of the expressions that the rule checks. An interface type such
+		// as parser.BinaryExpression matches all expressions that implements it. An empty NodeTypes
+		// matches all expressions
+		NodeTypes []reflect.Type
+
+		// Check checks the given expression and reports issues using the given context
+		Check func(ctx RuleContext, e parser.Expression)
+	}
+
+	// A RuleContext is passed to the Check function of a Rule
+	RuleContext interface {
+		// Report reports the issue of the rule at the given location. The location is typically the
+		// checked expression or one of its contents. A *parser.Positioned can be used to report a
+		// location that doesn't correspond to an expression
+		Report(location issue.Location, args issue.H)
+
+		// Container returns the container of the checked expression
+		Container() parser.Expression
+
+		// ContainerOf returns the container of some parent of the checked expression
+		ContainerOf(e parser.Expression) parser.Expression
+
+		// Path returns the containers of the checked expression, outermost first. The returned
+		// slice is only valid during the call to Check
+		Path() []parser.Expression
+	}
+
+	ruleContext struct {
+		v    *AbstractValidator
+		rule *Rule
+	}
+)
+
+var rules = make(map[issue.Code]*Rule)
+
+// RegisterRule registers a rule so that it becomes available to all validators. This function
+// panics if a rule for the same code has already been registered or if no issue exists for the code.
+func RegisterRule(rule *Rule) {
+	issue.ForCode(rule.Code)
+	if _, found := rules[rule.Code]; found {
+		panic(fmt.Sprintf(`Attempt to register a second rule for the issue '%s'`, rule.Code))
+	}
+	if rule.Check == nil {
+		panic(fmt.Sprintf(`The rule for the issue '%s' has no Check function`, rule.Code))
+	}
+	if rule.Severity == 0 {
+		rule.Severity = issue.SeverityError
+	}
+	rule.Severity.AssertValid()
+	rules[rule.Code] = rule
+}
+
+// RuleForCode returns the registered rule for the given code together with a bool indicating if
+// the rule was found or not
+func RuleForCode(code issue.Code) (rule *Rule, ok bool) {
+	rule, ok = rules[code]
+	return
+}
+
+// Rules returns all registered rules sorted by code
+func Rules() []*Rule {
+	result := make([]*Rule, 0, len(rules))
+	for _, rule := range rules {
+		result = append(result, rule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Code < result[j].Code })
+	return result
+}
+
+// RulePack returns all registered rules that belongs to the given pack sorted by code
+func RulePack(pack string) []*Rule {
+	result := make([]*Rule, 0)
+	for _, rule := range Rules() {
+		if rule.Pack == pack {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// Matches returns true if the rule should check expressions of the given type
+func (r *Rule) Matches(t reflect.Type) bool {
+	if len(r.NodeTypes) == 0 {
+		return true
+	}
+	for _, nt := range r.NodeTypes {
+		if t == nt || nt.Kind() == reflect.Interface && t.Implements(nt) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnableRule enables the registered rule for the given code. This function panics if no such rule exists.
+func (v *AbstractValidator) EnableRule(code issue.Code) {
+	v.setRuleEnabled(code, true)
+}
+
+// DisableRule disables the registered rule for the given code. This function panics if no such rule exists.
+func (v *AbstractValidator) DisableRule(code issue.Code) {
+	v.setRuleEnabled(code, false)
+}
+
+// RuleEnabled returns true if the registered rule for the given code will be applied by this validator
+func (v *AbstractValidator) RuleEnabled(code issue.Code) bool {
+	if enabled, ok := v.enabledRules[code]; ok {
+		return enabled
+	}
+	if rule, ok := rules[code]; ok {
+		return rule.Enabled
+	}
+	return false
+}
+
+func (v *AbstractValidator) setRuleEnabled(code issue.Code, enabled bool) {
+	if _, ok := rules[code]; !ok {
+		panic(fmt.Sprintf(`No rule is registered for the issue '%s'`, code))
+	}
+	if v.enabledRules == nil {
+		v.enabledRules = make(map[issue.Code]bool)
+	}
+	v.enabledRules[code] = enabled
+	v.rulesByType = nil
+}
+
+// applyRules applies all enabled rules that matches the type of the given expression
+func (v *AbstractValidator) applyRules(e parser.Expression) {
+	if v.rulesByType == nil {
+		v.rulesByType = make(map[reflect.Type][]*Rule)
+	}
+	t := reflect.TypeOf(e)
+	applicable, ok := v.rulesByType[t]
+	if !ok {
+		for _, rule := range Rules() {
+			if v.RuleEnabled(rule.Code) && rule.Matches(t) {
+				applicable = append(applicable, rule)
+			}
+		}
+		v.rulesByType[t] = applicable
+	}
+	for _, rule := range applicable {
+		rule.Check(&ruleContext{v, rule}, e)
+	}
+}
+
+func (c *ruleContext) Report(location issue.Location, args issue.H) {
+	c.v.report(c.rule.Code, c.rule.Severity, location, args)
+}
+
+func (c *ruleContext) Container() parser.Expression {
+	return c.v.Container()
+}
+
+func (c *ruleContext) ContainerOf(e parser.Expression) parser.Expression {
+	return c.v.ContainerOf(e)
+}
+
+func (c *ruleContext) Path() []parser.Expression {
+	return c.v.path
+}
+
+// packRule registers a rule that reports warnings and isn't enabled by default
+func packRule(pack string, code issue.Code, check func(RuleContext, parser.Expression), nodes ...parser.Expression) {
+	types := make([]reflect.Type, len(nodes))
+	for i, n := range nodes {
+		types[i] = reflect.TypeOf(n)
+	}
+	RegisterRule(&Rule{
+		Code:      code,
+		Severity:  issue.SeverityWarning,
+		Pack:      pack,
+		NodeTypes: types,
+		Check:     check})
+}
+
+func location(locator *parser.Locator, offset, length int) issue.Location {
+	p := &parser.Positioned{}
+	p.Init(locator, offset, length)
+	return p
+}
+
+func isResourceOfType(e parser.Expression, typeName string) bool {
+	if re, ok := e.(*parser.ResourceExpression); ok {
+		if qn, ok := re.TypeName().(*parser.QualifiedName); ok {
+			return strings.ToLower(qn.Name()) == typeName
+		}
+	}
+	return false
+}
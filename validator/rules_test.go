@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+const (
+	testContainer        = `TEST_CONTAINER`
+	testForbiddenClass   = `TEST_FORBIDDEN_CLASS`
+	testNoMultiplication = `TEST_NO_MULTIPLICATION`
+)
+
+func init() {
+	issue.Hard2(testContainer, `%{expression} in %{container} contained in %{root}`,
+		issue.HF{`expression`: issue.Label, `container`: issue.Label, `root`: issue.Label})
+	issue.Soft(testForbiddenClass, `Classes must not be named 'forbidden'`)
+	issue.Hard(testNoMultiplication, `The operator '%{operator}' is not allowed`)
+
+	RegisterRule(&Rule{
+		Code:      testForbiddenClass,
+		Severity:  issue.SeverityWarning,
+		Enabled:   true,
+		NodeTypes: []reflect.Type{reflect.TypeOf(&parser.HostClassDefinition{})},
+		Check: func(ctx RuleContext, e parser.Expression) {
+			if e.(*parser.HostClassDefinition).Name() == `forbidden` {
+				ctx.Report(e, issue.NoArgs)
+			}
+		}})
+
+	RegisterRule(&Rule{
+		Code:      testNoMultiplication,
+		NodeTypes: []reflect.Type{reflect.TypeOf((*parser.BinaryExpression)(nil)).Elem()},
+		Check: func(ctx RuleContext, e parser.Expression) {
+			if a, ok := e.(*parser.ArithmeticExpression); ok && a.Operator() == `*` {
+				ctx.Report(e, issue.H{`operator`: a.Operator()})
+			}
+		}})
+
+	RegisterRule(&Rule{
+		Code:      testContainer,
+		NodeTypes: []reflect.Type{reflect.TypeOf(&parser.LiteralInteger{})},
+		Check: func(ctx RuleContext, e parser.Expression) {
+			ctx.Report(e, issue.H{`expression`: e, `container`: ctx.Container(), `root`: ctx.Path()[0]})
+		}})
+}
+
+func TestRuleEnabledByDefault(t *testing.T) {
+	issues := validateWith(t, NewChecker(StrictError), `class forbidden {} class bar {}`)
+	expectIssueCodes(t, issues, testForbiddenClass)
+	if issues[0].Severity() != issue.SeverityWarning {
+		t.Errorf(`expected severity warning, got %s`, issues[0].Severity())
+	}
+}
+
+func TestRuleDisabled(t *testing.T) {
+	v := NewChecker(StrictError)
+	v.DisableRule(testForbiddenClass)
+	expectIssueCodes(t, validateWith(t, v, `class forbidden {}`))
+}
+
+func TestRuleDemoted(t *testing.T) {
+	v := &basicChecker{}
+	v.initialize(StrictError)
+	v.Demote(testForbiddenClass, issue.SeverityIgnore)
+	expectIssueCodes(t, validateWith(t, v, `class forbidden {}`))
+}
+
+func TestRuleEnabled(t *testing.T) {
+	expectIssueCodes(t, validateWith(t, NewChecker(StrictError), `notice(2 * 3)`))
+
+	v := NewChecker(StrictError)
+	v.EnableRule(testNoMultiplication)
+	issues := validateWith(t, v, `notice(2 * 3 + 1)`)
+	expectIssueCodes(t, issues, testNoMultiplication)
+	if issues[0].Severity() != issue.SeverityError {
+		t.Errorf(`expected severity error, got %s`, issues[0].Severity())
+	}
+}
+
+func TestRuleContext(t *testing.T) {
+	v := NewChecker(StrictError)
+	v.EnableRule(testContainer)
+	issues := validateWith(t, v, `notice(1 + 2)`)
+	expectIssueCodes(t, issues, testContainer, testContainer)
+	if s := issues[0].String(); s != `Literal Integer in '+' expression contained in Program (line: 1, column: 8)` {
+		t.Errorf(`unexpected message %s`, s)
+	}
+}
+
+func TestRegisterRuleTwice(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error(`expected a panic when registering a rule twice`)
+		}
+	}()
+	RegisterRule(&Rule{Code: testForbiddenClass, Check: func(ctx RuleContext, e parser.Expression) {}})
+}
+
+func validateWith(t *testing.T, v Validator, source string) []issue.Reported {
+	t.Helper()
+	Validate(v, parse(t, source))
+	return v.Issues()
+}
+
+func expectIssueCodes(t *testing.T, issues []issue.Reported, codes ...issue.Code) {
+	t.Helper()
+	if len(issues) != len(codes) {
+		t.Fatalf(`expected %d issues, got %v`, len(codes), issues)
+	}
+	for i, code := range codes {
+		if issues[i].Code() != code {
+			t.Errorf(`expected %s, got %s`, code, issues[i].Code())
+		}
+	}
+}
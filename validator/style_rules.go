@@ -0,0 +1,194 @@
+package validator
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// StylePack is the name of the rule pack that checks the layout and the quoting style of the source.
+// The rules in this pack are not enabled by default.
+const StylePack = `style`
+
+// MaxLineLength is the maximum number of characters on a line accepted by the ValidateLineTooLong rule
+const MaxLineLength = 140
+
+var arrowExpr = regexp.MustCompile(`[=+]>`)
+
+func init() {
+	packRule(StylePack, ValidateArrowNotAligned, checkArrowAlignment, &parser.ResourceBody{})
+	packRule(StylePack, ValidateDoubleQuotedString, checkDoubleQuotedString, &parser.LiteralString{}, &parser.ConcatenatedString{})
+	packRule(StylePack, ValidateEnsureNotFirst, checkEnsureFirst, &parser.ResourceBody{})
+	packRule(StylePack, ValidateHardTab, checkHardTabs, &parser.Program{})
+	packRule(StylePack, ValidateLineTooLong, checkLineLength, &parser.Program{})
+	packRule(StylePack, ValidateTrailingWhitespace, checkTrailingWhitespace, &parser.Program{})
+	packRule(StylePack, ValidateUnbracedVariable, checkUnbracedVariable, &parser.TextExpression{})
+	packRule(StylePack, ValidateUnquotedFileMode, checkFileMode, &parser.ResourceBody{})
+}
+
+func checkArrowAlignment(ctx RuleContext, e parser.Expression) {
+	body := e.(*parser.ResourceBody)
+	ops := body.Operations()
+	if len(ops) < 2 {
+		return
+	}
+
+	locator := body.Locator()
+	source := locator.String()
+	lines := make(map[int]bool, len(ops))
+	arrows := make([]int, 0, len(ops))
+	expected := 0
+	for _, op := range ops {
+		line := op.Line()
+		if lines[line] {
+			// Alignment is not required when attributes share a line
+			return
+		}
+		lines[line] = true
+
+		start := op.ByteOffset()
+		ix := arrowExpr.FindStringIndex(source[start:])
+		if ix == nil {
+			return
+		}
+		arrow := start + ix[0]
+		arrows = append(arrows, arrow)
+
+		// The arrow must be at least one space after the end of the longest attribute name
+		nameEnd := start + len(strings.TrimRight(source[start:arrow], " \t"))
+		if col := locator.PosOnLine(nameEnd) + 1; col > expected {
+			expected = col
+		}
+	}
+
+	for _, arrow := range arrows {
+		if locator.PosOnLine(arrow) != expected {
+			ctx.Report(location(locator, arrow, 2), issue.H{`expected`: expected})
+		}
+	}
+}
+
+func checkDoubleQuotedString(ctx RuleContext, e parser.Expression) {
+	if cs, ok := e.(*parser.ConcatenatedString); ok {
+		segments := cs.Segments()
+		if len(segments) != 1 {
+			return
+		}
+		if _, ok = segments[0].(*parser.LiteralString); !ok {
+			return
+		}
+	} else if _, ok := ctx.Container().(*parser.ConcatenatedString); ok {
+		// A segment of an interpolated string
+		return
+	}
+
+	// Double quotes are motivated when the string contains escapes or single quotes
+	src := e.String()
+	if strings.HasPrefix(src, `"`) && !strings.ContainsAny(src, `\'$`) {
+		ctx.Report(e, issue.NoArgs)
+	}
+}
+
+func checkEnsureFirst(ctx RuleContext, e parser.Expression) {
+	first := true
+	for _, op := range e.(*parser.ResourceBody).Operations() {
+		if ao, ok := op.(*parser.AttributeOperation); ok {
+			if ao.Name() == `ensure` {
+				if !first {
+					ctx.Report(ao, issue.NoArgs)
+				}
+				return
+			}
+			first = false
+		}
+	}
+}
+
+func checkFileMode(ctx RuleContext, e parser.Expression) {
+	if !isResourceOfType(ctx.Container(), `file`) {
+		return
+	}
+	for _, op := range e.(*parser.ResourceBody).Operations() {
+		if ao, ok := op.(*parser.AttributeOperation); ok && ao.Name() == `mode` {
+			if mode, ok := ao.Value().(*parser.LiteralInteger); ok {
+				ctx.Report(mode, issue.H{`mode`: mode.String()})
+			}
+		}
+	}
+}
+
+func checkHardTabs(ctx RuleContext, e parser.Expression) {
+	eachSourceLine(e.(*parser.Program), func(locator *parser.Locator, offset int, line string) {
+		if ix := strings.IndexByte(line, '\t'); ix >= 0 {
+			ctx.Report(location(locator, offset+ix, 1), issue.NoArgs)
+		}
+	})
+}
+
+func checkLineLength(ctx RuleContext, e parser.Expression) {
+	eachSourceLine(e.(*parser.Program), func(locator *parser.Locator, offset int, line string) {
+		length := utf8.RuneCountInString(line)
+		if length <= MaxLineLength {
+			return
+		}
+		// Report the part of the line that exceeds the maximum
+		excess := 0
+		for i := 0; i < MaxLineLength; i++ {
+			_, sz := utf8.DecodeRuneInString(line[excess:])
+			excess += sz
+		}
+		ctx.Report(location(locator, offset+excess, len(line)-excess), issue.H{`length`: length, `max`: MaxLineLength})
+	})
+}
+
+func checkTrailingWhitespace(ctx RuleContext, e parser.Expression) {
+	eachSourceLine(e.(*parser.Program), func(locator *parser.Locator, offset int, line string) {
+		if end := len(strings.TrimRight(line, " \t")); end < len(line) {
+			ctx.Report(location(locator, offset+end, len(line)-end), issue.NoArgs)
+		}
+	})
+}
+
+func checkUnbracedVariable(ctx RuleContext, e parser.Expression) {
+	if _, ok := ctx.Container().(*parser.ConcatenatedString); !ok {
+		return
+	}
+	v, ok := e.(*parser.TextExpression).Expr().(*parser.VariableExpression)
+	if !ok {
+		return
+	}
+	src := e.Locator().String()[e.ByteOffset():]
+	if strings.HasPrefix(src, `$`) && !strings.HasPrefix(src, `${`) {
+		ctx.Report(e, issue.H{`name`: v.NameOrIndex()})
+	}
+}
+
+// eachSourceLine calls the given function once for each line of the source of the given program. The
+// line passed to the function doesn't include the line terminator. Templates are not checked since
+// their layout is determined by the text that they produce.
+func eachSourceLine(program *parser.Program, f func(locator *parser.Locator, offset int, line string)) {
+	if isEppProgram(program) {
+		return
+	}
+	locator := program.Locator()
+	source := locator.String()
+	for offset := 0; offset < len(source); {
+		end := strings.IndexByte(source[offset:], '\n')
+		if end < 0 {
+			end = len(source) - offset
+		}
+		f(locator, offset, strings.TrimSuffix(source[offset:offset+end], "\r"))
+		offset += end + 1
+	}
+}
+
+func isEppProgram(program *parser.Program) bool {
+	if l, ok := program.Body().(*parser.LambdaExpression); ok {
+		_, ok = l.Body().(*parser.EppExpression)
+		return ok
+	}
+	return false
+}
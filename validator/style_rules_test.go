@@ -0,0 +1,100 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestArrowAlignment(t *testing.T) {
+	expectStyleIssues(t, issue.Unindent(`
+    file { '/tmp/x':
+      ensure => file,
+      mode   => '0644',
+      * => $attrs,
+    }`)+"\n",
+		ValidateArrowNotAligned)
+
+	expectStyleIssues(t, issue.Unindent(`
+    file { '/tmp/x':
+      ensure   => file,
+      mode     => '0644',
+      content => 'x',
+    }`)+"\n",
+		ValidateArrowNotAligned, ValidateArrowNotAligned)
+
+	expectStyleIssues(t, issue.Unindent(`
+    file { '/tmp/x':
+      ensure  => file,
+      mode    => '0644',
+      require => File['/tmp'],
+    }
+    file { '/tmp/y': ensure => file, mode => '0644' }`)+"\n")
+}
+
+func TestArrowAlignmentMessage(t *testing.T) {
+	issues := validateStyle(t, "file { '/tmp/x':\n  ensure => file,\n  owner => 'root',\n}\n")
+	expectIssueCodes(t, issues, ValidateArrowNotAligned)
+	if s := issues[0].String(); s != `The arrow should be at column 10 to align with the other arrows of the resource body (line: 3, column: 9)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+}
+
+func TestDoubleQuotedString(t *testing.T) {
+	expectStyleIssues(t, `notice("hello")`+"\n", ValidateDoubleQuotedString)
+	expectStyleIssues(t, `notice('hello', "it's", "a\tb", "${x}", "x${y}z")`+"\n")
+}
+
+func TestEnsureNotFirst(t *testing.T) {
+	expectStyleIssues(t, "file { '/tmp/x': mode => '0644', ensure => file }\n", ValidateEnsureNotFirst)
+	expectStyleIssues(t, "file { '/tmp/x': * => $x, ensure => file, mode => '0644' }\n")
+}
+
+func TestUnquotedFileMode(t *testing.T) {
+	issues := validateStyle(t, "file { '/tmp/x': ensure => file, mode => 0644 }\n")
+	expectIssueCodes(t, issues, ValidateUnquotedFileMode)
+	if s := issues[0].String(); s != `The file mode 0644 should be a quoted string, e.g. '0644' (line: 1, column: 42)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+	expectStyleIssues(t, "exec { 'x': mode => 0644 }\n")
+}
+
+func TestSourceLines(t *testing.T) {
+	expectStyleIssues(t, "$x = 1 \n$y = 2\n", ValidateTrailingWhitespace)
+	expectStyleIssues(t, "if true {\n\t$y = 2\n}\n", ValidateHardTab)
+	expectStyleIssues(t, `$x = '`+strings.Repeat(`x`, MaxLineLength-7)+"'\n")
+
+	issues := validateStyle(t, `$x = '`+strings.Repeat(`x`, MaxLineLength-6)+"'\n")
+	expectIssueCodes(t, issues, ValidateLineTooLong)
+	if s := issues[0].String(); s != `The line has 141 characters which is more than the maximum of 140 (line: 1, column: 141)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+}
+
+func TestUnbracedVariable(t *testing.T) {
+	issues := validateStyle(t, `notice("x${a}y$b")`+"\n")
+	expectIssueCodes(t, issues, ValidateUnbracedVariable)
+	if s := issues[0].String(); s != `The variable '$b' is interpolated without enclosing braces. Use '${b}' (line: 1, column: 15)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+	expectStyleIssues(t, `notice("${a}")`+"\n")
+}
+
+func TestStyleRulesNotEnabledByDefault(t *testing.T) {
+	expectNoIssues(t, "notice(\"x\") \n")
+}
+
+func expectStyleIssues(t *testing.T, source string, codes ...issue.Code) {
+	t.Helper()
+	expectIssueCodes(t, validateStyle(t, source), codes...)
+}
+
+func validateStyle(t *testing.T, source string) []issue.Reported {
+	t.Helper()
+	v := NewChecker(StrictError)
+	for _, rule := range RulePack(StylePack) {
+		v.EnableRule(rule.Code)
+	}
+	return validateWith(t, v, source)
+}
@@ -0,0 +1,143 @@
+package validator
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// SuppressionPrefix is the prefix of the comment directives that suppress issues. The directives are:
+//
+//	# parser:disable CODE, ...            a trailing comment suppresses the issues on its line
+//	# parser:disable CODE, ...            a comment on its own line suppresses the issues until a matching enable
+//	# parser:enable CODE, ...             ends the suppression started by a disable
+//	# parser:disable-next-line CODE, ...  suppresses the issues on the next line
+//
+// A directive without codes applies to all issues. Hard issues cannot be suppressed.
+const SuppressionPrefix = `parser:`
+
+var directiveExpr = regexp.MustCompile(`\A` + SuppressionPrefix + `(disable-next-line|disable|enable)(?:\s+(.*))?\z`)
+
+var codeSeparatorExpr = regexp.MustCompile(`[\s,]+`)
+
+type suppression struct {
+	comment   *parser.Comment
+	code      issue.Code
+	firstLine int
+	lastLine  int
+	used      bool
+}
+
+// suppressions returns the suppressions declared by the directives in the comments of the given program
+func suppressions(program *parser.Program) []*suppression {
+	all := make([]*suppression, 0)
+	open := make([]*suppression, 0)
+	for _, comment := range program.Comments() {
+		m := directiveExpr.FindStringSubmatch(comment.Text())
+		if m == nil {
+			continue
+		}
+		codes := directiveCodes(m[2])
+		line := comment.Line()
+		switch m[1] {
+		case `disable-next-line`:
+			for _, code := range codes {
+				all = append(all, &suppression{comment: comment, code: code, firstLine: line + 1, lastLine: line + 1})
+			}
+		case `disable`:
+			for _, code := range codes {
+				s := &suppression{comment: comment, code: code, firstLine: line, lastLine: line}
+				if !comment.IsTrailing() {
+					// A block that extends until it is enabled again
+					s.lastLine = math.MaxInt32
+					open = append(open, s)
+				}
+				all = append(all, s)
+			}
+		case `enable`:
+			stillOpen := open[:0]
+			for _, s := range open {
+				if matchesCode(codes, s.code) {
+					s.lastLine = line
+				} else {
+					stillOpen = append(stillOpen, s)
+				}
+			}
+			open = stillOpen
+		}
+	}
+	return all
+}
+
+// directiveCodes returns the codes of a directive or a slice with an empty code when no codes are given
+func directiveCodes(s string) []issue.Code {
+	codes := make([]issue.Code, 0)
+	for _, code := range codeSeparatorExpr.Split(strings.TrimSpace(s), -1) {
+		if code != `` {
+			codes = append(codes, issue.Code(code))
+		}
+	}
+	if len(codes) == 0 {
+		codes = append(codes, ``)
+	}
+	return codes
+}
+
+func matchesCode(codes []issue.Code, code issue.Code) bool {
+	for _, c := range codes {
+		if c == `` || c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *suppression) suppresses(ri issue.Reported) bool {
+	if s.code != `` && s.code != ri.Code() {
+		return false
+	}
+	if i, ok := issue.ForCode2(ri.Code()); !ok || !i.IsDemotable() {
+		return false
+	}
+	loc := ri.Location()
+	return loc != nil && loc.File() == s.comment.File() && loc.Line() >= s.firstLine && loc.Line() <= s.lastLine
+}
+
+// applySuppressions removes the issues that are suppressed by directives in the comments of the given
+// expression, which must be a Program, and reports the suppressions that didn't suppress anything.
+func (v *AbstractValidator) applySuppressions(e parser.Expression) {
+	program, ok := e.(*parser.Program)
+	if !ok {
+		return
+	}
+	all := suppressions(program)
+	if len(all) == 0 {
+		return
+	}
+
+	kept := v.issues[:0]
+nextIssue:
+	for _, ri := range v.issues {
+		for _, s := range all {
+			if s.suppresses(ri) {
+				s.used = true
+				continue nextIssue
+			}
+		}
+		kept = append(kept, ri)
+	}
+	v.issues = kept
+
+	for _, s := range all {
+		if !s.used {
+			code := `all issues`
+			if s.code != `` {
+				code = `'` + string(s.code) + `'`
+			}
+			v.report(ValidateUnusedSuppression, issue.SeverityWarning, s.comment, issue.H{`code`: code})
+		}
+	}
+}
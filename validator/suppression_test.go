@@ -0,0 +1,69 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestSuppressTrailing(t *testing.T) {
+	expectStyleIssues(t, issue.Unindent(`
+    notice("a") # parser:disable VALIDATE_DOUBLE_QUOTED_STRING
+    notice("b")
+    `), ValidateDoubleQuotedString)
+
+	issues := validateStyle(t, "notice(\"a\") # parser:disable VALIDATE_DOUBLE_QUOTED_STRING\nnotice(\"b\")\n")
+	if issues[0].Location().Line() != 2 {
+		t.Errorf(`expected issue on line 2, got %s`, issues[0])
+	}
+}
+
+func TestSuppressNextLine(t *testing.T) {
+	expectStyleIssues(t, issue.Unindent(`
+    # parser:disable-next-line VALIDATE_DOUBLE_QUOTED_STRING, VALIDATE_TRAILING_WHITESPACE
+    notice("a")
+    notice("b")
+    `), ValidateDoubleQuotedString, ValidateUnusedSuppression)
+}
+
+func TestSuppressAll(t *testing.T) {
+	expectStyleIssues(t, issue.Unindent(`
+    notice("a") # parser:disable
+    `))
+}
+
+func TestSuppressBlock(t *testing.T) {
+	expectStyleIssues(t, issue.Unindent(`
+    # parser:disable VALIDATE_DOUBLE_QUOTED_STRING
+    notice("a")
+    notice("b")
+    # parser:enable VALIDATE_DOUBLE_QUOTED_STRING
+    notice("c")
+    `), ValidateDoubleQuotedString)
+
+	expectStyleIssues(t, issue.Unindent(`
+    /* parser:disable */
+    notice("a")
+    notice("b")
+    `))
+}
+
+func TestSuppressUnused(t *testing.T) {
+	issues := validateStyle(t, issue.Unindent(`
+    notice('a') # parser:disable VALIDATE_DOUBLE_QUOTED_STRING
+    # parser:disable-next-line
+    notice('b')
+    `))
+	expectIssueCodes(t, issues, ValidateUnusedSuppression, ValidateUnusedSuppression)
+	if s := issues[0].String(); s != `The suppression of 'VALIDATE_DOUBLE_QUOTED_STRING' is not used. No such issue was found where it applies (line: 1, column: 13)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+	if s := issues[1].String(); s != `The suppression of all issues is not used. No such issue was found where it applies (line: 2, column: 1)` {
+		t.Errorf(`unexpected message: %s`, s)
+	}
+}
+
+func TestSuppressHardIssue(t *testing.T) {
+	expectIssueCodes(t, parseAndValidate(t, "$x += 1 # parser:disable\n"),
+		ValidateAppendsDeletesNoLongerSupported, ValidateUnusedSuppression)
+}
@@ -1,24 +1,172 @@
 package validator
 
 import (
+	"strings"
+
 	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/literal"
 	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/plan"
 )
 
+// A PlanResolver resolves the plans that are run by other plans, e.g. a plan.Module
+type PlanResolver interface {
+	// Plan returns the signature of the plan with the given qualified name or nil if no such plan exists
+	Plan(name string) *plan.Signature
+}
+
 type tasksChecker struct {
 	basicChecker
+	resolver PlanResolver
+
+	// plans are the signatures of the plans of the validated program keyed by lower case name
+	program parser.Expression
+	plans   map[string]*plan.Signature
 }
 
 func NewTasksChecker() Checker {
-	tasksChecker := &tasksChecker{}
+	return NewTasksModuleChecker(nil)
+}
+
+// NewTasksModuleChecker returns a tasks checker that uses the given resolver to check the calls to run_plan
+// that run plans that aren't defined in the validated program. Only plans of the validated program are
+// checked when the resolver is nil.
+func NewTasksModuleChecker(resolver PlanResolver) Checker {
+	tasksChecker := &tasksChecker{resolver: resolver}
 	tasksChecker.initialize(StrictError)
 	return tasksChecker
 }
 
+// Validate validates the given expression using the tasks rules, unless the expression is in the body of an
+// apply block. Such a body contains catalog code that is validated using the rules of the basic checker.
 func (v *tasksChecker) Validate(e parser.Expression) {
+	if v.inApplyBody() {
+		Check(&v.basicChecker, e)
+		return
+	}
 	Check(v, e)
 }
 
+// inApplyBody returns true if the currently validated expression is in the body of an apply block
+func (v *tasksChecker) inApplyBody() bool {
+	for i, p := range v.path {
+		if apply, ok := p.(*parser.ApplyExpression); ok {
+			if i+1 < len(v.path) && v.path[i+1] == apply.Body() || i+1 == len(v.path) && v.subject == apply.Body() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (v *tasksChecker) checkCallNamedFunctionExpression(e *parser.CallNamedFunctionExpression) {
+	v.basicChecker.checkCallNamedFunctionExpression(e)
+	if fn, ok := e.Functor().(*parser.QualifiedName); ok && fn.Name() == `run_plan` {
+		v.checkRunPlan(e)
+	}
+}
+
+// checkRunPlan checks the arguments of a call to run_plan against the signature of the plan that it runs. The
+// plan is given by the first argument, the parameters by a trailing hash. An argument between the two is
+// the value of the 'targets' parameter. Only the literal parts of the call are checked.
+func (v *tasksChecker) checkRunPlan(e *parser.CallNamedFunctionExpression) {
+	args := e.Arguments()
+	if len(args) == 0 || len(args) > 3 {
+		return
+	}
+	name, ok := literal.ToLiteral(args[0])
+	if !ok {
+		return
+	}
+	planName, ok := name.(string)
+	if !ok {
+		return
+	}
+	sig := v.plan(planName)
+	if sig == nil {
+		return
+	}
+
+	// complete is false when the names of the given parameters are unknown
+	complete := true
+	given := make(map[string]bool)
+	check := func(name string, value, location parser.Expression) {
+		given[name] = true
+		p := sig.Parameter(name)
+		if p == nil {
+			v.Accept(ValidateUnknownPlanParameter, location, issue.H{`plan`: sig.Name, `name`: name, `suggestion`: parser.Suggestion(name, sig.ParameterNames())})
+			return
+		}
+		if t := p.ResolvedType(); t != nil {
+			// Fold the value since the type checks expect the hash representation produced by folding
+			if lv, err := literal.Fold(value); err == nil && !t.IsInstance(lv) {
+				v.Accept(ValidatePlanParameterTypeMismatch, value,
+					issue.H{`plan`: sig.Name, `name`: name, `expected`: t.String(), `actual`: literal.TypeString(lv)})
+			}
+		}
+	}
+
+	params := args[1:]
+	if len(params) > 0 {
+		if h, ok := params[len(params)-1].(*parser.LiteralHash); ok {
+			params = params[:len(params)-1]
+			for _, entry := range h.Entries() {
+				ke, ok := entry.(*parser.KeyedEntry)
+				if !ok {
+					complete = false
+					continue
+				}
+				key, ok := literal.ToLiteral(ke.Key())
+				if s, isString := key.(string); ok && isString {
+					// Parameters with a leading underscore are options of run_plan such as '_catch_errors'
+					if !strings.HasPrefix(s, `_`) {
+						check(s, ke.Value(), ke.Key())
+					}
+				} else {
+					complete = false
+				}
+			}
+		} else if len(params) == 2 {
+			complete = false
+			params = params[:1]
+		} else if _, ok := literal.ToLiteral(params[0]); !ok {
+			// A value that isn't literal can be either the targets or a hash of parameters
+			complete = false
+			params = params[:0]
+		}
+	}
+	if len(params) == 1 {
+		check(`targets`, params[0], params[0])
+	}
+
+	if complete {
+		for _, p := range sig.Parameters {
+			if p.Required && !given[p.Name] {
+				v.Accept(ValidateMissingPlanParameter, e, issue.H{`plan`: sig.Name, `name`: p.Name})
+			}
+		}
+	}
+}
+
+// plan returns the signature of the plan with the given name. Plans of the validated program take
+// precedence over the plans of the resolver.
+func (v *tasksChecker) plan(name string) *plan.Signature {
+	if len(v.path) > 0 && v.path[0] != v.program {
+		v.program = v.path[0]
+		v.plans = make(map[string]*plan.Signature)
+		for _, sig := range plan.Signatures(v.program) {
+			v.plans[strings.ToLower(sig.Name)] = sig
+		}
+	}
+	if sig, ok := v.plans[strings.ToLower(strings.TrimPrefix(name, `::`))]; ok {
+		return sig
+	}
+	if v.resolver != nil {
+		return v.resolver.Plan(name)
+	}
+	return nil
+}
+
 func (v *tasksChecker) illegalTasksExpression(e parser.Expression) {
 	v.Accept(ValidateCatalogOperationNotSupported, e, issue.H{`operation`: e})
 }
@@ -1,6 +1,13 @@
 package validator
 
-import "testing"
+import (
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/plan"
+)
 
 func TestTasksResourceValidation(t *testing.T) {
 	PuppetTasks = true
@@ -18,3 +25,158 @@ func TestTasksResourceValidation(t *testing.T) {
 
 	expectIssues(t, `@@class { my: message => 'syntax ok' }`, ValidateCatalogOperationNotSupported)
 }
+
+func TestTasksApplyValidation(t *testing.T) {
+	PuppetTasks = true
+	defer func() { PuppetTasks = false }()
+
+	expectNoIssues(t, issue.Unindent(`
+    plan deploy(TargetSpec $targets) {
+      run_task('prepare', $targets)
+      apply($targets, '_catch_errors' => true) {
+        package { 'nginx': ensure => installed }
+        class { 'nginx::config': }
+      }
+    }`))
+
+	expectIssues(t, issue.Unindent(`
+    plan deploy(TargetSpec $targets) {
+      apply($targets) {
+        notify { 'inside': }
+      }
+      notify { 'outside': }
+    }`),
+		ValidateCatalogOperationNotSupported)
+
+	// The body is validated using the rules of the basic checker
+	expectIssues(t, issue.Unindent(`
+    plan deploy(TargetSpec $targets) {
+      apply($targets) {
+        node default {}
+      }
+    }`),
+		ValidateNotTopLevel)
+
+	expectIssues(t, issue.Unindent(`
+    plan deploy(TargetSpec $targets) {
+      apply() {}
+      apply($targets, {}, 3) {}
+    }`),
+		ValidateApplyArguments)
+
+	expectIssues(t, `apply($targets) {}`, ValidateApplyOutsidePlan)
+}
+
+func TestTasksRunPlan(t *testing.T) {
+	PuppetTasks = true
+	defer func() { PuppetTasks = false }()
+
+	deploy := issue.Unindent(`
+    type Mymod::Port = Integer[1, 65535]
+
+    plan mymod::deploy(TargetSpec $targets, String $version, Mymod::Port $port = 8080, Optional[String] $note) {}
+    `)
+
+	expectNoIssues(t, deploy+issue.Unindent(`
+    plan mymod::main(TargetSpec $targets) {
+      run_plan('mymod::deploy', $targets, version => '1.0', port => 80, '_catch_errors' => true)
+      run_plan('mymod::deploy', { targets => 'web1', version => $version, note => undef })
+      run_plan('mymod::deploy', $params)
+      run_plan('mymod::deploy', $targets, $params)
+      run_plan('other::plan', nosuch => 1)
+    }`))
+
+	expectIssues(t, deploy+issue.Unindent(`
+    plan mymod::main(TargetSpec $targets) {
+      run_plan('mymod::deploy', targets => $targets)
+    }`),
+		ValidateMissingPlanParameter)
+
+	expectIssues(t, deploy+issue.Unindent(`
+    plan mymod::main(TargetSpec $targets) {
+      run_plan('mymod::deploy', $targets, version => '1.0', nosuch => 1)
+    }`),
+		ValidateUnknownPlanParameter)
+
+	issues := parseAndValidate(t, deploy+issue.Unindent(`
+    plan mymod::main(TargetSpec $targets) {
+      run_plan('mymod::deploy', $targets, verison => '1.0')
+    }`))
+	expectIssueCodes(t, issues, ValidateUnknownPlanParameter, ValidateMissingPlanParameter)
+	if !strings.Contains(issues[0].String(), `Did you mean 'version'?`) {
+		t.Errorf(`expected a suggestion, got %s`, issues[0].String())
+	}
+
+	expectIssues(t, deploy+issue.Unindent(`
+    plan mymod::main(TargetSpec $targets) {
+      run_plan('mymod::deploy', 'web1', { version => 1, port => 70000 })
+    }`),
+		ValidatePlanParameterTypeMismatch)
+
+	// A literal argument that isn't a hash gives the targets
+	expectIssues(t, issue.Unindent(`
+    plan mymod::other() {}
+
+    plan mymod::main(TargetSpec $targets) {
+      run_plan('mymod::other', ['web1', 'web2'])
+    }`),
+		ValidateUnknownPlanParameter)
+	options := issue.Unindent(`
+    plan mymod::options(TargetSpec $targets, Hash[String, Integer] $options) {}
+    `)
+	expectNoIssues(t, options+issue.Unindent(`
+    plan mymod::main() {
+      run_plan('mymod::options', 'web1', options => {a => 1, b => 2})
+    }`))
+	expectIssues(t, options+issue.Unindent(`
+    plan mymod::main() {
+      run_plan('mymod::options', 'web1', options => {a => 1, b => 'c'})
+    }`),
+		ValidatePlanParameterTypeMismatch)
+}
+
+// planMap is a PlanResolver of the plans of parsed sources
+type planMap map[string]*plan.Signature
+
+func (m planMap) Plan(name string) *plan.Signature {
+	return m[name]
+}
+
+func TestTasksRunPlanResolver(t *testing.T) {
+	PuppetTasks = true
+	defer func() { PuppetTasks = false }()
+
+	module := planMap{}
+	for _, sig := range plan.Signatures(parse(t, `plan mymod::deploy(TargetSpec $targets, String $version) {}`, parser.TasksEnabled)) {
+		module[sig.Name] = sig
+	}
+
+	source := issue.Unindent(`
+    plan mymod::main(TargetSpec $targets) {
+      run_plan('mymod::deploy', $targets, {})
+    }`)
+	expectNoIssues(t, source)
+
+	v := NewTasksModuleChecker(module)
+	Validate(v, parse(t, source, parser.TasksEnabled))
+	if issues := v.Issues(); len(issues) != 1 || issues[0].Code() != ValidateMissingPlanParameter {
+		t.Errorf(`expected %s, got %v`, ValidateMissingPlanParameter, issues)
+	}
+}
+
+func TestTasksStrictness(t *testing.T) {
+	source := `$x = {a => 1, a => 2}`
+	expectIssueCodes(t, validateWith(t, NewTasksChecker(), source), ValidateDuplicateKey)
+
+	v := NewTasksChecker()
+	SetStrictness(v, StrictOff)
+	expectIssueCodes(t, validateWith(t, v, source))
+
+	v = NewWorkflowChecker()
+	SetStrictness(v, StrictWarning)
+	issues := validateWith(t, v, source)
+	expectIssueCodes(t, issues, ValidateDuplicateKey)
+	if issues[0].Severity() != issue.SeverityWarning {
+		t.Errorf(`expected severity warning, got %s`, issues[0].Severity())
+	}
+}
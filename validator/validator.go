@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/lyraproj/issue/issue"
@@ -14,6 +15,9 @@ const (
 	StrictError   = Strictness(issue.SeverityError)
 )
 
+// noLocation is used for issues that are not related to a source location
+var noLocation = issue.NewLocation(``, 0, 0)
+
 type (
 	Validator interface {
 		Clear()
@@ -24,7 +28,20 @@ type (
 		// Return all reported issues (should be called after validation)
 		Issues() []issue.Reported
 
+		// Change the severity of the issue with the given code
+		Demote(code issue.Code, severity issue.Severity) error
+
+		// Enable the registered rule for the given code
+		EnableRule(code issue.Code)
+
+		// Disable the registered rule for the given code
+		DisableRule(code issue.Code)
+
 		setPathAndSubject(path []parser.Expression, expr parser.Expression)
+
+		applyRules(e parser.Expression)
+
+		applySuppressions(e parser.Expression)
 	}
 
 	ParserValidator interface {
@@ -35,10 +52,12 @@ type (
 
 	// All validators should "inherit" from this struct
 	AbstractValidator struct {
-		path       []parser.Expression
-		subject    parser.Expression
-		issues     []issue.Reported
-		severities map[issue.Code]issue.Severity
+		path         []parser.Expression
+		subject      parser.Expression
+		issues       []issue.Reported
+		severities   map[issue.Code]issue.Severity
+		enabledRules map[issue.Code]bool
+		rulesByType  map[reflect.Type][]*Rule
 	}
 
 	Strictness int
@@ -75,23 +94,58 @@ func (s Strictness) String() string {
 	}
 }
 
-func (v *AbstractValidator) Demote(code issue.Code, severity issue.Severity) {
-	i := issue.ForCode(code)
-	severity.AssertValid()
-	if !i.IsDemotable() {
-		panic(fmt.Sprintf(`Attempt to demote the hard issue '%s' to %s`, code, severity.String()))
+// Demote changes the severity of the issue with the given code. An error is returned if the code is
+// unknown, if the severity is invalid, or if the issue is a hard issue and the severity isn't error.
+func (v *AbstractValidator) Demote(code issue.Code, severity issue.Severity) error {
+	i, ok := issue.ForCode2(code)
+	if !ok {
+		return issue.NewReported(ValidateUnknownIssue, issue.SeverityError, issue.H{`code`: code}, noLocation)
+	}
+	switch severity {
+	case issue.SeverityIgnore, issue.SeverityDeprecation, issue.SeverityWarning, issue.SeverityError:
+	default:
+		return issue.NewReported(ValidateInvalidSeverity, issue.SeverityError, issue.H{`severity`: int(severity)}, noLocation)
+	}
+	if !i.IsDemotable() && severity != issue.SeverityError {
+		return issue.NewReported(ValidateNotDemotable, issue.SeverityError, issue.H{`code`: code, `severity`: severity.String()}, noLocation)
+	}
+	if v.severities == nil {
+		v.severities = make(map[issue.Code]issue.Severity, 5)
 	}
 	v.severities[code] = severity
+	return nil
+}
+
+// SetStrictness changes the severity of the issues that are controlled by the given strictness in the
+// given validator. It panics if the strictness is invalid.
+func SetStrictness(v Validator, strict Strictness) {
+	for _, code := range []issue.Code{ValidateDuplicateKey, ValidateIdemExpressionNotLast} {
+		if err := v.Demote(code, issue.Severity(strict)); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// mustDemote is like Demote but panics on errors. It is used for the severities that a validator sets
+// up by itself.
+func (v *AbstractValidator) mustDemote(code issue.Code, severity issue.Severity) {
+	if err := v.Demote(code, severity); err != nil {
+		panic(err)
+	}
 }
 
 // Accept an issue during validation
 func (v *AbstractValidator) Accept(code issue.Code, e parser.Expression, args issue.H) {
+	v.report(code, issue.SeverityError, e, args)
+}
+
+func (v *AbstractValidator) report(code issue.Code, defaultSeverity issue.Severity, location issue.Location, args issue.H) {
 	severity, ok := v.severities[code]
 	if !ok {
-		severity = issue.SeverityError
+		severity = defaultSeverity
 	}
 	if severity != issue.SeverityIgnore {
-		v.issues = append(v.issues, issue.NewReported(code, severity, args, e))
+		v.issues = append(v.issues, issue.NewReported(code, severity, args, location))
 	}
 }
 
@@ -153,17 +207,22 @@ func ValidateWorkflow(e parser.Expression) Validator {
 }
 
 // Iterate over all expressions contained in the given expression (including the expression itself)
-// and validate each one.
+// and validate each one. The enabled rules of the validator are applied to each expression after it
+// has been validated. Issues suppressed by comment directives in the source are then removed, see
+// SuppressionPrefix.
 func Validate(v Validator, e parser.Expression) {
 	path := make([]parser.Expression, 0, 16)
 
 	v.Clear()
 	v.setPathAndSubject(path, e)
 	v.Validate(e)
+	v.applyRules(e)
 	e.AllContents(path, func(path []parser.Expression, expr parser.Expression) {
 		v.setPathAndSubject(path, expr)
 		v.Validate(expr)
+		v.applyRules(expr)
 	})
+	v.applySuppressions(e)
 }
 
 func NewParserValidator(parser parser.ExpressionParser, validator Validator) ParserValidator {
@@ -1,17 +1,53 @@
 package validator
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/lyraproj/issue/issue"
 	"github.com/lyraproj/puppet-parser/parser"
+	"github.com/lyraproj/puppet-parser/workflow"
 )
 
+// iterationArity describes the parameters and variables of an iteration function
+type iterationArity struct {
+	params         int
+	minVars        int
+	maxVars        int
+	expectedParams string
+	expectedVars   string
+}
+
+var iterationArities = map[string]iterationArity{
+	`each`:  {1, 1, 2, `one parameter, the collection`, `one variable for each element or two variables for each key and value`},
+	`range`: {2, 1, 1, `two parameters, the start and the end of the range`, `one variable, the current value`},
+	`times`: {1, 1, 1, `one parameter, the number of iterations`, `one variable, the index`},
+}
+
+// A StepResolver resolves the steps that are called by other steps, e.g. a workflow.Module
+type StepResolver interface {
+	// Step returns the step with the given qualified name or nil if no such step exists
+	Step(name string) *parser.StepExpression
+
+	// StepNames returns the qualified names of all steps known to the resolver
+	StepNames() []string
+}
+
 type workflowChecker struct {
 	tasksChecker
+	resolver StepResolver
 }
 
 func NewWorkflowChecker() Checker {
-	wfChecker := &workflowChecker{}
+	return NewModuleChecker(nil)
+}
+
+// NewModuleChecker returns a workflow checker that uses the given resolver to check the steps that are
+// called by other steps. Calls are not checked when the resolver is nil.
+func NewModuleChecker(resolver StepResolver) Checker {
+	wfChecker := &workflowChecker{resolver: resolver}
 	wfChecker.initialize(StrictError)
+	wfChecker.mustDemote(ValidateReturnNotConsumed, issue.SeverityWarning)
 	return wfChecker
 }
 
@@ -20,6 +56,8 @@ func (v *workflowChecker) Validate(e parser.Expression) {
 }
 
 func (v *workflowChecker) checkStepExpression(e *parser.StepExpression) {
+	v.checkIteration(e)
+	v.checkCall(e)
 	switch e.Style() {
 	case parser.StepStyleAction:
 		v.checkAction(e)
@@ -35,13 +73,412 @@ func (v *workflowChecker) checkStepExpression(e *parser.StepExpression) {
 }
 
 func (v *workflowChecker) checkAction(e *parser.StepExpression) {
+	v.checkParametersAndReturns(e)
 }
 
 func (v *workflowChecker) checkStateHandler(e *parser.StepExpression) {
+	v.checkParametersAndReturns(e)
 }
 
+// checkResource checks that the state of the resource only references variables that are declared as
+// parameters of the resource or as variables of its iteration.
 func (v *workflowChecker) checkResource(e *parser.StepExpression) {
+	v.checkParametersAndReturns(e)
+	if e.Definition() == nil {
+		return
+	}
+	for _, ref := range workflow.StepDeferredReferences(e) {
+		if ref.Unresolvable {
+			v.Accept(ValidateUndeclaredStateVariable, ref.Expression(), issue.H{`step`: e.Name(), `name`: ref.Variable})
+		}
+	}
+
+	// Variables that the parser didn't convert into deferred references, e.g. operands of operators
+	resolvable := workflow.Resolvable(e)
+	e.Definition().AllContents(nil, func(path []parser.Expression, expr parser.Expression) {
+		if ve, ok := expr.(*parser.VariableExpression); ok {
+			if name, ok := ve.Name(); ok && !resolvable[name] && !strings.Contains(name, `::`) {
+				v.Accept(ValidateUndeclaredStateVariable, ve, issue.H{`step`: e.Name(), `name`: name})
+			}
+		}
+	})
 }
 
+// checkWorkflow checks the data flow between the steps of the workflow. Each parameter of a step must be
+// satisfied by a parameter of the workflow or by a value returned by another step, and so must each return
+// of the workflow. A value must not be returned by more than one step and should be consumed by another
+// step or returned by the workflow. The steps must not depend on each other in a cycle.
 func (v *workflowChecker) checkWorkflow(e *parser.StepExpression) {
+	v.checkParametersAndReturns(e)
+	steps := e.Steps()
+
+	producers := make(map[string]*parser.StepExpression)
+	for _, step := range steps {
+		for _, o := range workflow.Outputs(step) {
+			if other, found := producers[o.Name]; found && other != step {
+				v.Accept(ValidateReturnProducedTwice, o.Expression, issue.H{
+					`name`: o.Name, `step`: step.Name(), `style`: step.Style(), `other`: other.Name(), `other_style`: other.Style()})
+			} else if !found {
+				producers[o.Name] = step
+			}
+		}
+	}
+
+	available := workflow.Available(e)
+	consumed := make(map[string]bool)
+	for _, r := range parameters(e.Returns()) {
+		consumed[r.Name()] = true
+		// The returns of a workflow that calls another workflow are checked against the called workflow
+		if _, found := producers[r.Name()]; !found && !available[r.Name()] && e.Property(`call`) == nil {
+			v.Accept(ValidateUnsatisfiedReturn, r, issue.H{`name`: r.Name(), `step`: e.Name(), `style`: e.Style()})
+		}
+	}
+
+	for _, step := range steps {
+		for _, p := range workflow.Inputs(step) {
+			consumed[p.Name()] = true
+			if available[p.Name()] {
+				continue
+			}
+			if producer, found := producers[p.Name()]; found && producer != step {
+				continue
+			}
+			v.Accept(ValidateUnsatisfiedParameter, p, issue.H{`name`: p.Name(), `step`: step.Name(), `style`: step.Style()})
+		}
+	}
+
+	for _, step := range steps {
+		for _, o := range workflow.Outputs(step) {
+			if !consumed[o.Name] && producers[o.Name] == step {
+				// Report each name only once
+				consumed[o.Name] = true
+				v.Accept(ValidateReturnNotConsumed, o.Expression, issue.H{`name`: o.Name, `step`: step.Name(), `style`: step.Style()})
+			}
+		}
+	}
+	v.checkStepOrder(e)
+}
+
+// checkStepOrder checks that the steps of the workflow don't depend on each other in a cycle and that each
+// step can run. A step that can't run because one of its parameters is unsatisfied has already been
+// reported, as has a step that is part of a cycle.
+func (v *workflowChecker) checkStepOrder(e *parser.StepExpression) {
+	g := workflow.NewGraph(e)
+	inCycle := make(map[*workflow.Node]bool)
+	for _, cycle := range g.Cycles() {
+		names := make([]string, len(cycle))
+		for i, n := range cycle {
+			names[i] = `'` + n.Name + `'`
+			inCycle[n] = true
+		}
+		v.Accept(ValidateStepCycle, cycle[0].Step(), issue.H{`steps`: strings.Join(names, `, `), `workflow`: e.Name()})
+	}
+	for _, n := range g.Unreachable() {
+		if !inCycle[n] && len(n.Unsatisfied) == 0 {
+			v.Accept(ValidateUnreachableStep, n.Step(), issue.H{`step`: n.Name, `style`: n.Style})
+		}
+	}
+}
+
+// checkCall checks that a step that calls another step calls a step of the same style that is declared in
+// the module, that the parameters and returns of the calling step match those of the called step, and that
+// the called step doesn't include a workflow that encloses the calling step.
+func (v *workflowChecker) checkCall(e *parser.StepExpression) {
+	if e.Property(`call`) == nil {
+		return
+	}
+	name := e.Call()
+	if name == `` {
+		v.Accept(ValidateIllegalCall, e.Property(`call`), issue.H{`step`: e.Name(), `style`: e.Style()})
+		return
+	}
+	if v.resolver == nil {
+		return
+	}
+	target := v.resolver.Step(name)
+	if target == nil {
+		v.Accept(ValidateUnresolvedStep, e.Property(`call`), issue.H{`name`: name, `step`: e.Name(), `style`: e.Style(),
+			`suggestion`: parser.Suggestion(name, v.resolver.StepNames())})
+		return
+	}
+	args := func(h issue.H) issue.H {
+		h[`step`] = e.Name()
+		h[`style`] = e.Style()
+		h[`target`] = target.Name()
+		h[`target_style`] = target.Style()
+		return h
+	}
+	if target.Style() != e.Style() {
+		v.Accept(ValidateCallStyleMismatch, e.Property(`call`), args(issue.H{}))
+		return
+	}
+
+	accepted := make(map[string]*parser.Parameter)
+	for _, params := range [][]parser.Expression{target.Parameters(), target.IterationParameters()} {
+		for _, p := range parameters(params) {
+			accepted[p.Name()] = p
+		}
+	}
+	declared := make(map[string]bool)
+	for _, p := range parameters(e.Parameters()) {
+		declared[p.Name()] = true
+		if tp, found := accepted[p.Name()]; !found {
+			v.Accept(ValidateUnknownCallParameter, p, args(issue.H{`name`: p.Name(), `suggestion`: parser.Suggestion(p.Name(), parameterNames(accepted))}))
+		} else {
+			v.checkCallType(p, tp, `parameter`, args)
+		}
+	}
+	for _, p := range parameters(e.IterationVariables()) {
+		declared[p.Name()] = true
+	}
+	for _, p := range workflow.Inputs(target) {
+		if !declared[p.Name()] {
+			v.Accept(ValidateMissingCallParameter, e, args(issue.H{`name`: p.Name()}))
+		}
+	}
+
+	returned := make(map[string]*parser.Parameter)
+	for _, r := range parameters(target.Returns()) {
+		returned[r.Name()] = r
+	}
+	for _, r := range parameters(e.Returns()) {
+		if tr, found := returned[r.Name()]; !found {
+			v.Accept(ValidateUnknownCallReturn, r, args(issue.H{`name`: r.Name(), `suggestion`: parser.Suggestion(r.Name(), parameterNames(returned))}))
+		} else {
+			v.checkCallType(r, tr, `return value`, args)
+		}
+	}
+
+	enclosing := make(map[string]bool)
+	for _, p := range append([]parser.Expression{e}, v.path...) {
+		if step, ok := p.(*parser.StepExpression); ok && step.Style() == parser.StepStyleWorkflow {
+			enclosing[step.Name()] = true
+		}
+	}
+	if wf := v.includedWorkflow(target, enclosing, make(map[*parser.StepExpression]bool)); wf != `` {
+		v.Accept(ValidateRecursiveWorkflow, e.Property(`call`), args(issue.H{`workflow`: wf}))
+	}
+}
+
+// checkCallType checks that the type of a parameter or a return of a calling step is equal to the type of
+// the corresponding parameter or return of the called step when both are declared
+func (v *workflowChecker) checkCallType(p, tp *parser.Parameter, kind string, args func(issue.H) issue.H) {
+	if p.Type() == nil || tp.Type() == nil {
+		return
+	}
+	t, tt := typeString(p.Type()), typeString(tp.Type())
+	if t != tt {
+		v.Accept(ValidateCallTypeMismatch, p.Type(), args(issue.H{`kind`: kind, `name`: p.Name(), `type`: t, `target_type`: tt}))
+	}
+}
+
+// includedWorkflow returns the name of the first of the given workflows that the given step is, contains, or
+// calls, directly or through the steps that it contains or calls, or the empty string if there is no such
+// workflow
+func (v *workflowChecker) includedWorkflow(step *parser.StepExpression, workflows map[string]bool, visited map[*parser.StepExpression]bool) string {
+	if visited[step] {
+		return ``
+	}
+	visited[step] = true
+	if workflows[step.Name()] {
+		return step.Name()
+	}
+	if name := step.Call(); name != `` {
+		if target := v.resolver.Step(name); target != nil {
+			if wf := v.includedWorkflow(target, workflows, visited); wf != `` {
+				return wf
+			}
+		}
+	}
+	for _, s := range step.Steps() {
+		if wf := v.includedWorkflow(s, workflows, visited); wf != `` {
+			return wf
+		}
+	}
+	return ``
+}
+
+// checkIteration checks that the iteration of the step has the parameters and the variables that its
+// function expects, that the variables don't clash with the parameters of the step, and that the name under
+// which the iteration exposes the returns of the step is neither a parameter nor a return of the step.
+func (v *workflowChecker) checkIteration(e *parser.StepExpression) {
+	function := e.IterationFunction()
+	arity, ok := iterationArities[function]
+	if !ok {
+		return
+	}
+	iteration := e.Property(`iteration`)
+	if params := e.IterationParameters(); len(params) != arity.params {
+		v.Accept(ValidateIterationParameterCount, iteration, issue.H{
+			`function`: function, `step`: e.Name(), `style`: e.Style(), `expected`: arity.expectedParams, `count`: len(params)})
+	}
+	vars := parameters(e.IterationVariables())
+	if len(vars) < arity.minVars || len(vars) > arity.maxVars {
+		v.Accept(ValidateIterationVariableCount, iteration, issue.H{
+			`function`: function, `step`: e.Name(), `style`: e.Style(), `expected`: arity.expectedVars, `count`: len(vars)})
+	}
+
+	declared := make(map[string]bool)
+	for _, p := range parameters(e.Parameters()) {
+		declared[p.Name()] = true
+	}
+	unique := make(map[string]bool, len(vars))
+	for _, p := range vars {
+		if unique[p.Name()] {
+			v.Accept(ValidateDuplicateIterationVariable, p, issue.H{`name`: p.Name(), `step`: e.Name(), `style`: e.Style()})
+			continue
+		}
+		unique[p.Name()] = true
+		if declared[p.Name()] {
+			v.Accept(ValidateIterationVariableClash, p, issue.H{`name`: p.Name(), `step`: e.Name(), `style`: e.Style()})
+		}
+	}
+
+	name := e.IterationName()
+	returns := parameters(e.Returns())
+	if len(returns) == 0 {
+		// A step that is not assigned is named after itself and may be iterated for its side effects only
+		if name != simpleName(e.Name()) {
+			v.Accept(ValidateIterationWithoutReturns, iteration, issue.H{`name`: name, `step`: e.Name(), `style`: e.Style()})
+		}
+		return
+	}
+	kind := ``
+	if declared[name] {
+		kind = `parameter`
+	} else {
+		for _, r := range returns {
+			if r.Name() == name {
+				kind = `return`
+				break
+			}
+		}
+	}
+	if kind != `` {
+		v.Accept(ValidateIterationNameClash, iteration, issue.H{`name`: name, `kind`: kind, `step`: e.Name(), `style`: e.Style()})
+	}
+}
+
+// checkParametersAndReturns checks that the parameters and the returns of the step are well formed and
+// unique, that no parameter captures rest, and that only a resource has returns that alias attributes.
+func (v *workflowChecker) checkParametersAndReturns(e *parser.StepExpression) {
+	v.checkDeclarations(e, `parameters`, `value`, e.Parameters())
+	v.checkDeclarations(e, `returns`, `alias`, e.Returns())
+	params := make([]parser.Expression, 0)
+	for _, p := range parameters(e.Parameters()) {
+		params = append(params, p)
+	}
+	v.checkNoCapture(e, params)
+	v.checkParameterNameUniqueness(e, params)
+
+	returns := parameters(e.Returns())
+	unique := make(map[string]bool, len(returns))
+	for _, r := range returns {
+		if unique[r.Name()] {
+			v.Accept(ValidateDuplicateReturn, r, issue.H{`name`: r.Name(), `step`: e.Name(), `style`: e.Style()})
+		} else {
+			unique[r.Name()] = true
+		}
+		if r.Value() != nil && e.Style() != parser.StepStyleResource {
+			v.Accept(ValidateIllegalReturnAlias, r, issue.H{`name`: r.Name(), `step`: e.Name(), `style`: e.Style()})
+		}
+	}
+}
+
+// checkDeclarations checks the elements of a parameters or returns property that the parser didn't
+// convert into parameters. Such an element is either a hash that is not a valid expanded declaration or
+// something else than a hash.
+func (v *workflowChecker) checkDeclarations(e *parser.StepExpression, property, valueKey string, decls []parser.Expression) {
+	for _, decl := range decls {
+		switch decl := decl.(type) {
+		case *parser.Parameter:
+			continue
+		case *parser.LiteralHash:
+			hasName := false
+			for _, entry := range decl.Entries() {
+				ke, ok := entry.(*parser.KeyedEntry)
+				if !ok {
+					continue
+				}
+				key, _ := declarationName(ke.Key())
+				switch key {
+				case `name`:
+					_, hasName = declarationName(ke.Value())
+				case `type`:
+				case valueKey:
+					if property == `returns` && !isAlias(ke.Value()) {
+						v.Accept(ValidateIllegalDeclarationAlias, ke.Value(), issue.H{`step`: e.Name(), `style`: e.Style()})
+					}
+				default:
+					v.Accept(ValidateIllegalDeclarationKey, ke.Key(), issue.H{
+						`key`: key, `property`: property, `value_key`: valueKey, `step`: e.Name(), `style`: e.Style()})
+				}
+			}
+			if !hasName {
+				v.Accept(ValidateIllegalDeclarationName, decl, issue.H{`property`: property, `step`: e.Name(), `style`: e.Style()})
+			}
+		default:
+			v.Accept(ValidateMixedDeclarations, decl, issue.H{`property`: property, `step`: e.Name(), `style`: e.Style(), `value`: decl})
+		}
+	}
+}
+
+// declarationName returns the string of a literal string or a bare word
+func declarationName(e parser.Expression) (string, bool) {
+	switch e := e.(type) {
+	case *parser.LiteralString:
+		return e.StringValue(), true
+	case *parser.QualifiedName:
+		return e.Name(), true
+	case *parser.ReservedWord:
+		return e.Name(), true
+	}
+	return ``, false
+}
+
+// isAlias returns true if the given expression is an attribute name or a list of attribute names
+func isAlias(e parser.Expression) bool {
+	if list, ok := e.(*parser.LiteralList); ok {
+		for _, element := range list.Elements() {
+			if _, ok := declarationName(element); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	_, ok := declarationName(e)
+	return ok
+}
+
+// typeString returns the source of the given type expression without whitespace
+func typeString(e parser.Expression) string {
+	return strings.Join(strings.Fields(e.String()), ``)
+}
+
+// simpleName returns the last segment of the given qualified name
+func simpleName(name string) string {
+	if i := strings.LastIndex(name, `::`); i >= 0 {
+		return name[i+2:]
+	}
+	return name
+}
+
+func parameters(exprs []parser.Expression) []*parser.Parameter {
+	params := make([]*parser.Parameter, 0, len(exprs))
+	for _, e := range exprs {
+		if p, ok := e.(*parser.Parameter); ok {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// parameterNames returns the sorted names of the given parameters
+func parameterNames(params map[string]*parser.Parameter) []string {
+	result := make([]string, 0, len(params))
+	for name := range params {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
 }
@@ -1,6 +1,13 @@
 package validator
 
-import "testing"
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
 
 func TestWorkflowResourceValidation(t *testing.T) {
 	PuppetWorkflow = true
@@ -21,3 +28,563 @@ func TestWorkflowResourceValidation(t *testing.T) {
 	expectNoIssues(t, `workflow foo {}`)
 
 }
+
+func TestWorkflowParametersAndReturns(t *testing.T) {
+	PuppetWorkflow = true
+	defer func() { PuppetWorkflow = false }()
+
+	expectNoIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => (String $region),
+      returns => ($vpc_id)
+    } {
+      resource vpc {
+        parameters => ($region),
+        returns => ($vpc_id = vpcId),
+        type => Aws::Vpc
+      } {
+        region => $region
+      }
+    }`))
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => (String $region, Integer $region)
+    } {}`),
+		ValidateDuplicateParameter)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {} {
+      action bar {
+        parameters => (*$values)
+      } {}
+    }`),
+		ValidateCapturesRestNotSupported, ValidateUnsatisfiedParameter)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      returns => ($x, $x)
+    } {}`),
+		ValidateDuplicateReturn, ValidateUnsatisfiedReturn)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      returns => ($x)
+    } {
+      action bar {
+        returns => ($x = value)
+      } {}
+    }`),
+		ValidateIllegalReturnAlias)
+}
+
+func TestWorkflowDataFlow(t *testing.T) {
+	PuppetWorkflow = true
+	defer func() { PuppetWorkflow = false }()
+
+	expectNoIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($a),
+      returns => ($c)
+    } {
+      action first {
+        parameters => ($a),
+        returns => ($b)
+      } {}
+      action second {
+        parameters => ($b, $d = 'default'),
+        returns => ($c)
+      } {}
+    }`))
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      returns => ($b)
+    } {
+      action first {
+        parameters => ($a),
+        returns => ($b)
+      } {}
+    }`),
+		ValidateUnsatisfiedParameter)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      returns => ($b)
+    } {
+      action first {
+        parameters => ($b),
+        returns => ($b)
+      } {}
+    }`),
+		ValidateUnsatisfiedParameter)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      returns => ($b)
+    } {
+      action first {
+        returns => ($b)
+      } {}
+      action second {
+        returns => ($b)
+      } {}
+    }`),
+		ValidateReturnProducedTwice)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {} {
+      action first {
+        returns => ($b)
+      } {}
+    }`),
+		ValidateReturnNotConsumed)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      returns => ($bar)
+    } {
+      resource bar {
+        returns => ($y)
+      } each($list) |$x| {
+        value => $x
+      }
+    }`),
+		ValidateUnsatisfiedParameter)
+
+	expectNoIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($list),
+      returns => ($ys)
+    } {
+      resource bar {
+        returns => ($y)
+      } $ys = each($list) |$x| {
+        value => $x
+      }
+    }`))
+}
+
+func TestWorkflowReturns(t *testing.T) {
+	PuppetWorkflow = true
+	defer func() { PuppetWorkflow = false }()
+
+	// A return of a workflow is returned by a step or is a parameter of the workflow
+	expectNoIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($a),
+      returns => ($a, $b)
+    } {
+      action bar {
+        parameters => ($a),
+        returns => ($b)
+      } {}
+    }`))
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($a),
+      returns => ($b, $missing)
+    } {
+      action bar {
+        parameters => ($a),
+        returns => ($b)
+      } {}
+    }`),
+		ValidateUnsatisfiedReturn)
+}
+
+func TestWorkflowResourceState(t *testing.T) {
+	PuppetWorkflow = true
+	defer func() { PuppetWorkflow = false }()
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($region)
+    } {
+      resource vpc {
+        parameters => ($region)
+      } {
+        region => $region,
+        tags => { name => $name },
+        cidr => $cidrs[0],
+        zone => $facts::zone
+      }
+    }`),
+		ValidateUndeclaredStateVariable, ValidateUndeclaredStateVariable)
+}
+
+func TestWorkflowStepOrder(t *testing.T) {
+	PuppetWorkflow = true
+	defer func() { PuppetWorkflow = false }()
+
+	expectIssues(t, issue.Unindent(`
+    workflow loop {
+      parameters => ($a)
+    } {
+      action one {
+        parameters => ($a, $c),
+        returns => ($b)
+      } {}
+      action two {
+        parameters => ($b),
+        returns => ($c, $d)
+      } {}
+      action three {
+        parameters => ($d)
+      } {}
+    }`),
+		ValidateStepCycle, ValidateUnreachableStep)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {} {
+      action one {
+        parameters => ($a),
+        returns => ($b)
+      } {}
+      action two {
+        parameters => ($b)
+      } {}
+    }`),
+		ValidateUnsatisfiedParameter, ValidateUnreachableStep)
+}
+
+func TestWorkflowExpandedDeclarations(t *testing.T) {
+	PuppetWorkflow = true
+	defer func() { PuppetWorkflow = false }()
+
+	expectNoIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => [{ name => region, type => String, value => 'eu-west-1' }],
+      returns => [{ name => vpc_id, type => String }]
+    } {
+      resource vpc {
+        parameters => [{ name => region, type => String }],
+        returns => [{ name => vpc_id, alias => vpcId }],
+        type => Aws::Vpc
+      } {
+        region => $region
+      }
+    }`))
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => [{ name => a, typ => String }]
+    } {}`),
+		ValidateIllegalDeclarationKey)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => [{ name => a }, $b]
+    } {}`),
+		ValidateMixedDeclarations)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => [{ type => String }, { name => 3 }]
+    } {}`),
+		ValidateIllegalDeclarationName)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      returns => [{ name => a, alias => 3 }, { name => b, value => x }]
+    } {}`),
+		ValidateIllegalDeclarationAlias, ValidateIllegalDeclarationKey)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => [{ name => a }, { name => a }]
+    } {}`),
+		ValidateDuplicateParameter)
+}
+
+func TestWorkflowIteration(t *testing.T) {
+	PuppetWorkflow = true
+	defer func() { PuppetWorkflow = false }()
+
+	expectNoIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($names, $first, $count),
+      returns => ($ids, $indexes)
+    } {
+      resource bar {
+        returns => ($id)
+      } $ids = each($names) |$name| {
+        name => $name
+      }
+      action baz {
+        returns => ($index)
+      } $indexes = range($first, $count) |$i| {}
+      action qux {} times($count) |$i| {}
+    }`))
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($names)
+    } {
+      action bar {} each($names, $names) |$n| {}
+    }`),
+		ValidateIterationParameterCount)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($count)
+    } {
+      action bar {} times($count) |$i, $j| {}
+    }`),
+		ValidateIterationVariableCount)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($count)
+    } {
+      action bar {} range($count) |$i| {}
+    }`),
+		ValidateIterationParameterCount)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($pairs)
+    } {
+      action bar {} each($pairs) |$k, $k| {}
+    }`),
+		ValidateDuplicateIterationVariable)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($names)
+    } {
+      action bar {
+        parameters => ($name)
+      } each($names) |$name| {}
+    }`),
+		ValidateIterationVariableClash, ValidateUnsatisfiedParameter)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($names),
+      returns => ($id)
+    } {
+      action bar {
+        returns => ($id)
+      } $id = each($names) |$name| {}
+    }`),
+		ValidateIterationNameClash)
+
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($names)
+    } {
+      action bar {} $ids = each($names) |$name| {}
+    }`),
+		ValidateIterationWithoutReturns)
+
+	// The returns of an iterated step are only exposed under the name of the iteration
+	expectIssues(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($names),
+      returns => ($ids)
+    } {
+      action bar {
+        returns => ($id)
+      } $ids = each($names) |$name| {}
+      action baz {
+        parameters => ($id)
+      } {}
+    }`),
+		ValidateUnsatisfiedParameter)
+}
+
+// stepMap is a StepResolver of the steps of parsed sources
+type stepMap map[string]*parser.StepExpression
+
+func (m stepMap) Step(name string) *parser.StepExpression {
+	return m[name]
+}
+
+func (m stepMap) StepNames() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestWorkflowCalls(t *testing.T) {
+	module := stepMap{}
+	for _, source := range []string{
+		issue.Unindent(`
+      workflow network::setup {
+        parameters => (String $region, $zone = 'a'),
+        returns => (String $vpc_id)
+      } {
+        action create {
+          parameters => ($region, $zone),
+          returns => ($vpc_id)
+        } {}
+      }`),
+		issue.Unindent(`
+      workflow loop {
+        parameters => ($region)
+      } {
+        workflow again {
+          call => loop,
+          parameters => ($region)
+        }
+      }`),
+	} {
+		parse(t, source, parser.WorkflowEnabled).AllContents(nil, func(path []parser.Expression, e parser.Expression) {
+			if step, ok := e.(*parser.StepExpression); ok {
+				module[step.Name()] = step
+			}
+		})
+	}
+
+	expectCallIssues := func(source string, expectedIssueCodes ...issue.Code) {
+		t.Helper()
+		v := NewModuleChecker(module)
+		Validate(v, parse(t, issue.Unindent(source), parser.WorkflowEnabled))
+		expected := make(map[issue.Code]bool, len(expectedIssueCodes))
+		for _, code := range expectedIssueCodes {
+			expected[code] = true
+		}
+		produced := make(map[issue.Code]bool)
+		for _, i := range v.Issues() {
+			produced[i.Code()] = true
+			if !expected[i.Code()] {
+				t.Errorf(`unexpected issue %s`, i.String())
+			}
+		}
+		for _, code := range expectedIssueCodes {
+			if !produced[code] {
+				t.Errorf(`expected issue %s but it was not produced`, code)
+			}
+		}
+	}
+
+	expectCallIssues(`
+    workflow attach {
+      parameters => ($region),
+      returns => ($vpc_id)
+    } {
+      workflow network {
+        call => 'network::setup',
+        parameters => (String $region),
+        returns => ($vpc_id)
+      }
+    }`)
+
+	expectCallIssues(`
+    workflow attach {
+      parameters => ($region),
+      returns => ($vpc_id)
+    } {
+      workflow network {
+        call => network::setup,
+        parameters => (Integer $region, $name),
+        returns => ($vpc_id, $subnet_id)
+      }
+    }`,
+		ValidateCallTypeMismatch, ValidateUnknownCallParameter, ValidateUnknownCallReturn, ValidateUnsatisfiedParameter,
+		ValidateReturnNotConsumed)
+
+	expectCallIssues(`
+    workflow attach {} {
+      workflow network {
+        call => network::setup
+      }
+      action create {
+        call => network::setup,
+      } {}
+      action other {
+        call => not::declared
+      } {}
+      action broken {
+        call => 3
+      } {}
+    }`,
+		ValidateMissingCallParameter, ValidateCallStyleMismatch, ValidateUnresolvedStep, ValidateIllegalCall)
+
+	// The region is provided by the iteration of the calling step
+	expectCallIssues(`
+    workflow attach {
+      parameters => ($regions)
+    } {
+      workflow network {
+        call => network::setup
+      } each($regions) |$region| {}
+    }`)
+
+	expectCallIssues(`
+    workflow outer {
+      parameters => ($region)
+    } {
+      workflow inner {
+        call => loop,
+        parameters => ($region)
+      }
+    }`)
+
+	expectCallIssues(`
+    workflow loop {
+      parameters => ($region)
+    } {
+      workflow again {
+        call => loop,
+        parameters => ($region)
+      }
+    }`,
+		ValidateRecursiveWorkflow)
+}
+
+func TestWorkflowCallSuggestions(t *testing.T) {
+	module := stepMap{}
+	parse(t, issue.Unindent(`
+    workflow network::setup {
+      parameters => (String $region),
+      returns => (String $vpc_id)
+    } {
+      action create {
+        parameters => ($region),
+        returns => ($vpc_id)
+      } {}
+    }`), parser.WorkflowEnabled).AllContents(nil, func(path []parser.Expression, e parser.Expression) {
+		if step, ok := e.(*parser.StepExpression); ok {
+			module[step.Name()] = step
+		}
+	})
+
+	v := NewModuleChecker(module)
+	Validate(v, parse(t, issue.Unindent(`
+    workflow attach {
+      parameters => ($region),
+      returns => ($vpc_id)
+    } {
+      workflow network {
+        call => network::setup,
+        parameters => ($region, $regoin),
+        returns => ($vpc_id, $vpcid)
+      }
+      workflow other {
+        call => network::setpu
+      }
+    }`), parser.WorkflowEnabled))
+
+	suggestions := map[issue.Code]string{
+		ValidateUnknownCallParameter: `Did you mean 'region'?`,
+		ValidateUnknownCallReturn:    `Did you mean 'vpc_id'?`,
+		ValidateUnresolvedStep:       `Did you mean 'network::setup'?`,
+	}
+	for _, i := range v.Issues() {
+		if suggestion, ok := suggestions[i.Code()]; ok {
+			delete(suggestions, i.Code())
+			if !strings.Contains(i.String(), suggestion) {
+				t.Errorf(`expected %s to contain %q`, i.String(), suggestion)
+			}
+		}
+	}
+	for code := range suggestions {
+		t.Errorf(`expected issue %s but it was not produced`, code)
+	}
+}
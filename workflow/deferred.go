@@ -0,0 +1,101 @@
+package workflow
+
+import (
+	"strings"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// A DeferredReference is a variable or a function call in the state of a resource step that the parser
+// converted into a Deferred that is resolved when the resource is applied
+type DeferredReference struct {
+	// Step is the qualified name of the resource step
+	Step string `json:"step"`
+
+	// Variable is the name of a referenced variable
+	Variable string `json:"variable,omitempty"`
+
+	// Function is the name of a called function
+	Function string `json:"function,omitempty"`
+
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+
+	// Unresolvable is true for a variable that is neither a parameter of the step nor a parameter or a
+	// variable of its iteration. Such a variable can never be resolved.
+	Unresolvable bool `json:"unresolvable,omitempty"`
+
+	expr parser.Expression
+}
+
+// DeferredReferences returns the deferred references in the state of all resource steps in the given
+// expression, in the order that they appear in the source. The arguments of a deferred function call
+// follow the call.
+func DeferredReferences(e parser.Expression) []*DeferredReference {
+	refs := make([]*DeferredReference, 0)
+	visit := func(e parser.Expression) {
+		if step, ok := e.(*parser.StepExpression); ok && step.Style() == parser.StepStyleResource && step.Definition() != nil {
+			refs = append(refs, StepDeferredReferences(step)...)
+		}
+	}
+	visit(e)
+	e.AllContents(nil, func(path []parser.Expression, e parser.Expression) { visit(e) })
+	return refs
+}
+
+// StepDeferredReferences returns the deferred references in the state of the given resource step
+func StepDeferredReferences(step *parser.StepExpression) []*DeferredReference {
+	refs := make([]*DeferredReference, 0)
+	if step.Definition() == nil {
+		return refs
+	}
+	resolvable := Resolvable(step)
+	step.Definition().AllContents(nil, func(path []parser.Expression, e parser.Expression) {
+		call, ok := e.(*parser.CallMethodExpression)
+		if !ok {
+			return
+		}
+		d := deferred(call)
+		if d == nil {
+			return
+		}
+		refs = append(refs, &DeferredReference{
+			Step:         step.Name(),
+			Variable:     d.Variable,
+			Function:     d.Function,
+			File:         call.File(),
+			Line:         call.Line(),
+			Column:       call.Pos(),
+			Unresolvable: d.Variable != `` && !resolvable[d.Variable] && !strings.Contains(d.Variable, `::`),
+			expr:         call})
+	})
+	return refs
+}
+
+// Resolvable returns the names of the variables that a deferred reference in the state of the given step can
+// be resolved to. Those are the parameters of the step and the parameters and the variables of its iteration.
+func Resolvable(step *parser.StepExpression) map[string]bool {
+	resolvable := make(map[string]bool)
+	for _, params := range [][]parser.Expression{step.Parameters(), step.IterationParameters(), step.IterationVariables()} {
+		for _, e := range params {
+			if p, ok := e.(*parser.Parameter); ok {
+				resolvable[p.Name()] = true
+			}
+		}
+	}
+	return resolvable
+}
+
+// Expression returns the Deferred.new call that the parser created for the reference
+func (r *DeferredReference) Expression() parser.Expression {
+	return r.expr
+}
+
+// String returns the variable with a leading '$' or the function name followed by '()'
+func (r *DeferredReference) String() string {
+	if r.Variable != `` {
+		return `$` + r.Variable
+	}
+	return r.Function + `()`
+}
@@ -0,0 +1,47 @@
+package workflow
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+func TestDeferredReferences(t *testing.T) {
+	refs := DeferredReferences(parse(t, issue.Unindent(`
+    workflow attach {
+      parameters => ($region, $names)
+    } {
+      resource vpc {
+        parameters => ($region),
+        type => Aws::Vpc
+      } {
+        region => $region,
+        tags => { owner => lookup('owner', $zone) },
+        scope => $settings::scope
+      }
+      resource subnet {
+        type => Aws::Subnet
+      } each($names) |$name| {
+        name => $name
+      }
+    }`)))
+
+	expected := []string{
+		`attach::vpc $region 8:15`,
+		`attach::vpc lookup() 9:24`,
+		`attach::vpc $zone 9:40 unresolvable`,
+		`attach::vpc $settings::scope 10:14`,
+		`attach::subnet $name 15:13`}
+	actual := make([]string, len(refs))
+	for i, ref := range refs {
+		actual[i] = fmt.Sprintf(`%s %s %d:%d`, ref.Step, ref, ref.Line, ref.Column)
+		if ref.Unresolvable {
+			actual[i] += ` unresolvable`
+		}
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
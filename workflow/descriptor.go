@@ -0,0 +1,288 @@
+package workflow
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/lyraproj/puppet-parser/literal"
+	"github.com/lyraproj/puppet-parser/parser"
+	"gopkg.in/yaml.v2"
+)
+
+type (
+	// A Descriptor is a normalized description of a step that doesn't depend on the AST or on an evaluator.
+	// It is written as JSON or YAML with the keys given by the field tags. Empty fields are omitted.
+	Descriptor struct {
+		// Name is the qualified name of the step, e.g. 'attach::vpc'
+		Name string `json:"name" yaml:"name"`
+
+		// Style is one of 'action', 'resource', 'stateHandler', or 'workflow'
+		Style string `json:"style" yaml:"style"`
+
+		Parameters []*Declaration `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+		Returns    []*Declaration `json:"returns,omitempty" yaml:"returns,omitempty"`
+		Iteration  *Iteration     `json:"iteration,omitempty" yaml:"iteration,omitempty"`
+
+		// Type is the type of the resource of a resource step
+		Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+		// Properties are the properties of the step other than its parameters, returns, iteration, and type
+		Properties map[string]interface{} `json:"properties,omitempty" yaml:"properties,omitempty"`
+
+		// State is the desired state of the resource of a resource step
+		State map[string]interface{} `json:"state,omitempty" yaml:"state,omitempty"`
+
+		// Steps are the steps of a workflow
+		Steps []*Descriptor `json:"steps,omitempty" yaml:"steps,omitempty"`
+	}
+
+	// A Declaration is a parameter or a return of a step or a parameter or a variable of an iteration
+	Declaration struct {
+		Name string `json:"name" yaml:"name"`
+
+		// Type is the source of the type expression, e.g. 'Array[String]'
+		Type string `json:"type,omitempty" yaml:"type,omitempty"`
+
+		// Value is the default value of a parameter
+		Value interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+
+		// Alias is the attribute name, or list of attribute names, of the resource that a return is read from
+		Alias interface{} `json:"alias,omitempty" yaml:"alias,omitempty"`
+	}
+
+	// An Iteration describes how a step is run once for each element of a collection, for each value of a
+	// range, or a number of times
+	Iteration struct {
+		// Function is one of 'each', 'range', or 'times'
+		Function string `json:"function" yaml:"function"`
+
+		// Name is the name under which the returns of all iterations are exposed
+		Name string `json:"name" yaml:"name"`
+
+		Parameters []*Declaration `json:"parameters" yaml:"parameters"`
+		Variables  []*Declaration `json:"variables" yaml:"variables"`
+	}
+
+	// A Deferred is a value of the state of a resource that is resolved when the resource is applied. It is
+	// either a parameter of the resource, given by Variable, or the result of calling Function with
+	// Arguments. It is written as a hash with the single key 'deferred'.
+	Deferred struct {
+		Variable  string        `json:"variable,omitempty" yaml:"variable,omitempty"`
+		Function  string        `json:"function,omitempty" yaml:"function,omitempty"`
+		Arguments []interface{} `json:"arguments,omitempty" yaml:"arguments,omitempty"`
+	}
+
+	// An Unresolved is a value that is neither literal nor deferred, e.g. an arithmetic expression. It is
+	// written as a hash with the single key 'expression' whose value is the source of the expression.
+	Unresolved struct {
+		Source string
+	}
+)
+
+// plainDeferred has no marshal methods so that the fields of a Deferred can be marshalled
+type plainDeferred Deferred
+
+func (d *Deferred) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]*plainDeferred{`deferred`: (*plainDeferred)(d)})
+}
+
+func (d *Deferred) MarshalYAML() (interface{}, error) {
+	return map[string]*plainDeferred{`deferred`: (*plainDeferred)(d)}, nil
+}
+
+func (u *Unresolved) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{`expression`: u.Source})
+}
+
+func (u *Unresolved) MarshalYAML() (interface{}, error) {
+	return map[string]string{`expression`: u.Source}, nil
+}
+
+// Descriptors returns the descriptors of all steps in the given expression that are not nested in another
+// step, in the order that they appear in the source. The steps of a workflow are described by its descriptor.
+func Descriptors(e parser.Expression) []*Descriptor {
+	descriptors := make([]*Descriptor, 0)
+	if step, ok := e.(*parser.StepExpression); ok {
+		return append(descriptors, NewDescriptor(step))
+	}
+	e.AllContents(nil, func(path []parser.Expression, e parser.Expression) {
+		if step, ok := e.(*parser.StepExpression); ok {
+			for _, p := range path {
+				if _, nested := p.(*parser.StepExpression); nested {
+					return
+				}
+			}
+			descriptors = append(descriptors, NewDescriptor(step))
+		}
+	})
+	return descriptors
+}
+
+// NewDescriptor returns the descriptor of the given step
+func NewDescriptor(step *parser.StepExpression) *Descriptor {
+	d := &Descriptor{
+		Name:       step.Name(),
+		Style:      string(step.Style()),
+		Parameters: declarations(step.Parameters(), false),
+		Returns:    declarations(step.Returns(), true)}
+
+	if fn := step.IterationFunction(); fn != `` {
+		d.Iteration = &Iteration{
+			Function:   fn,
+			Name:       step.IterationName(),
+			Parameters: declarations(step.IterationParameters(), false),
+			Variables:  declarations(step.IterationVariables(), false)}
+	}
+
+	if hash, ok := step.Properties().(*parser.LiteralHash); ok {
+		for _, entry := range hash.Entries() {
+			ke, ok := entry.(*parser.KeyedEntry)
+			if !ok {
+				continue
+			}
+			switch key := keyString(ke.Key()); key {
+			case `parameters`, `returns`, `iteration`:
+			case `type`:
+				if step.Style() == parser.StepStyleResource {
+					d.Type = ke.Value().String()
+					continue
+				}
+				fallthrough
+			default:
+				if d.Properties == nil {
+					d.Properties = make(map[string]interface{})
+				}
+				d.Properties[key] = value(ke.Value())
+			}
+		}
+	}
+
+	switch step.Style() {
+	case parser.StepStyleResource:
+		if state, ok := value(step.Definition()).(map[string]interface{}); ok && len(state) > 0 {
+			d.State = state
+		}
+	case parser.StepStyleWorkflow:
+		for _, s := range step.Steps() {
+			d.Steps = append(d.Steps, NewDescriptor(s))
+		}
+	}
+	return d
+}
+
+// WriteDescriptorsJSON writes the given descriptors as a JSON array
+func WriteDescriptorsJSON(descriptors []*Descriptor, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(``, `  `)
+	return enc.Encode(descriptors)
+}
+
+// WriteDescriptorsYAML writes the given descriptors as a YAML sequence
+func WriteDescriptorsYAML(descriptors []*Descriptor, w io.Writer) error {
+	bs, err := yaml.Marshal(descriptors)
+	if err == nil {
+		_, err = w.Write(bs)
+	}
+	return err
+}
+
+func declarations(exprs []parser.Expression, returns bool) []*Declaration {
+	decls := make([]*Declaration, 0, len(exprs))
+	for _, e := range exprs {
+		p, ok := e.(*parser.Parameter)
+		if !ok {
+			continue
+		}
+		d := &Declaration{Name: p.Name()}
+		if p.Type() != nil {
+			d.Type = p.Type().String()
+		}
+		if p.Value() != nil {
+			if returns {
+				d.Alias = value(p.Value())
+			} else {
+				d.Value = value(p.Value())
+			}
+		}
+		decls = append(decls, d)
+	}
+	return decls
+}
+
+// value returns the value of the given expression as a string, number, boolean, nil, slice, string keyed
+// map, *Deferred, or *Unresolved
+func value(e parser.Expression) interface{} {
+	switch e := e.(type) {
+	case nil:
+		return nil
+	case *parser.LiteralList:
+		elements := e.Elements()
+		result := make([]interface{}, len(elements))
+		for i, element := range elements {
+			result[i] = value(element)
+		}
+		return result
+	case *parser.LiteralHash:
+		result := make(map[string]interface{}, len(e.Entries()))
+		for _, entry := range e.Entries() {
+			if ke, ok := entry.(*parser.KeyedEntry); ok {
+				result[keyString(ke.Key())] = value(ke.Value())
+			}
+		}
+		return result
+	case *parser.CallMethodExpression:
+		if d := deferred(e); d != nil {
+			return d
+		}
+	case *parser.QualifiedName:
+		return e.Name()
+	case *parser.QualifiedReference:
+		return e.Name()
+	case *parser.LiteralDefault:
+		return `default`
+	}
+	if v, ok := literal.ToLiteral(e); ok {
+		return v
+	}
+	return &Unresolved{parser.SourceOf(e)}
+}
+
+// deferred returns the Deferred of a Deferred.new call that the parser created from a variable reference or
+// a function call in the state of a resource, or nil if the given expression is not such a call
+func deferred(e *parser.CallMethodExpression) *Deferred {
+	na, ok := e.Functor().(*parser.NamedAccessExpression)
+	if !ok {
+		return nil
+	}
+	if ref, ok := na.Lhs().(*parser.QualifiedReference); !ok || ref.Name() != `Deferred` {
+		return nil
+	}
+	args := e.Arguments()
+	switch len(args) {
+	case 1:
+		if s, ok := args[0].(*parser.LiteralString); ok && len(s.StringValue()) > 1 && s.StringValue()[0] == '$' {
+			return &Deferred{Variable: s.StringValue()[1:]}
+		}
+	case 2:
+		d := &Deferred{Function: keyString(args[0])}
+		if list, ok := value(args[1]).([]interface{}); ok && len(list) > 0 {
+			d.Arguments = list
+		}
+		return d
+	}
+	return nil
+}
+
+// keyString returns the string of a literal string or a bare word, or the source of any other expression
+func keyString(e parser.Expression) string {
+	switch e := e.(type) {
+	case *parser.LiteralString:
+		return e.StringValue()
+	case *parser.QualifiedName:
+		return e.Name()
+	case *parser.ReservedWord:
+		return e.Name()
+	}
+	return parser.SourceOf(e)
+}
@@ -0,0 +1,183 @@
+package workflow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+)
+
+var deploy = issue.Unindent(`
+  workflow deploy {
+    parameters => (String $region = 'eu-west-1', Array[String] $names),
+    returns => ($vpc_id, $ids)
+  } {
+    resource vpc {
+      parameters => ($region),
+      returns => (String $vpc_id = vpcId),
+      type => Aws::Vpc
+    } {
+      region => $region,
+      tags => { owner => lookup('owner'), size => $region + 1 },
+      enabled => true
+    }
+    action tag {
+      parameters => ($vpc_id),
+      returns => ($id),
+      guard => false
+    } $ids = each($names) |$name| {}
+  }`)
+
+func TestDescriptorJSON(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	if err := WriteDescriptorsJSON(parseDescriptors(t, deploy), b); err != nil {
+		t.Fatal(err)
+	}
+	expectOutput(t, b, `[
+  {
+    "name": "deploy",
+    "style": "workflow",
+    "parameters": [
+      {
+        "name": "region",
+        "type": "String",
+        "value": "eu-west-1"
+      },
+      {
+        "name": "names",
+        "type": "Array[String]"
+      }
+    ],
+    "returns": [
+      {
+        "name": "vpc_id"
+      },
+      {
+        "name": "ids"
+      }
+    ],
+    "steps": [
+      {
+        "name": "deploy::vpc",
+        "style": "resource",
+        "parameters": [
+          {
+            "name": "region"
+          }
+        ],
+        "returns": [
+          {
+            "name": "vpc_id",
+            "type": "String",
+            "alias": "vpcId"
+          }
+        ],
+        "type": "Aws::Vpc",
+        "state": {
+          "enabled": true,
+          "region": {
+            "deferred": {
+              "variable": "region"
+            }
+          },
+          "tags": {
+            "owner": {
+              "deferred": {
+                "function": "lookup",
+                "arguments": [
+                  "owner"
+                ]
+              }
+            },
+            "size": {
+              "expression": "$region + 1"
+            }
+          }
+        }
+      },
+      {
+        "name": "deploy::tag",
+        "style": "action",
+        "parameters": [
+          {
+            "name": "vpc_id"
+          }
+        ],
+        "returns": [
+          {
+            "name": "id"
+          }
+        ],
+        "iteration": {
+          "function": "each",
+          "name": "ids",
+          "parameters": [
+            {
+              "name": "names"
+            }
+          ],
+          "variables": [
+            {
+              "name": "name"
+            }
+          ]
+        },
+        "properties": {
+          "guard": false
+        }
+      }
+    ]
+  }
+]
+`)
+}
+
+func TestDescriptorYAML(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	if err := WriteDescriptorsYAML(parseDescriptors(t, issue.Unindent(`
+    workflow foo {} {
+      resource bar {
+        parameters => ($x),
+        type => Foo
+      } {
+        value => $x,
+        list => [1, 'two']
+      }
+    }`)), b); err != nil {
+		t.Fatal(err)
+	}
+	expectOutput(t, b, `- name: foo
+  style: workflow
+  steps:
+  - name: foo::bar
+    style: resource
+    parameters:
+    - name: x
+    type: Foo
+    state:
+      list:
+      - 1
+      - two
+      value:
+        deferred:
+          variable: x
+`)
+}
+
+func TestDescriptors(t *testing.T) {
+	descriptors := parseDescriptors(t, issue.Unindent(`
+    workflow outer {} {
+      workflow inner {} {
+        action a {} {}
+      }
+    }`))
+	if len(descriptors) != 1 || len(descriptors[0].Steps) != 1 || descriptors[0].Steps[0].Name != `outer::inner` ||
+		len(descriptors[0].Steps[0].Steps) != 1 || descriptors[0].Steps[0].Steps[0].Name != `outer::inner::a` {
+		t.Errorf(`expected the descriptor of 'outer' to contain 'outer::inner' which contains 'outer::inner::a'`)
+	}
+}
+
+func parseDescriptors(t *testing.T, source string) []*Descriptor {
+	t.Helper()
+	return Descriptors(parse(t, source))
+}
@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Graphviz shapes of the nodes of each step style
+var dotShapes = map[string]string{
+	`action`:       `box`,
+	`resource`:     `ellipse`,
+	`stateHandler`: `diamond`,
+	`workflow`:     `box3d`,
+}
+
+// Mermaid delimiters of the nodes of each step style
+var mermaidShapes = map[string][2]string{
+	`action`:       {`[`, `]`},
+	`resource`:     {`([`, `])`},
+	`stateHandler`: {`{{`, `}}`},
+	`workflow`:     {`[[`, `]]`},
+}
+
+// WriteDOT writes the graph in the Graphviz DOT language. Each step style is drawn with a different shape
+// and each edge is labeled with the name of the value that flows from one step to the other.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	b := bytes.NewBufferString(``)
+	fmt.Fprintf(b, "digraph %s {\n", dotID(g.Name))
+	b.WriteString("  rankdir=LR;\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(b, "  %s [label=%s, shape=%s];\n", dotID(n.Name), dotID(n.Name), dotShapes[n.Style])
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s -> %s [label=%s];\n", dotID(e.From), dotID(e.To), dotID(e.Value))
+	}
+	b.WriteString("}\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart titled with the name of the workflow. Each step style
+// is drawn with a different shape and each edge is labeled with the name of the value that flows from one
+// step to the other.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	b := bytes.NewBufferString(``)
+	fmt.Fprintf(b, "---\ntitle: %s\n---\nflowchart LR\n", g.Name)
+	ids := make(map[string]string, len(g.Nodes))
+	for i, n := range g.Nodes {
+		id := fmt.Sprintf(`n%d`, i)
+		ids[n.Name] = id
+		shape := mermaidShapes[n.Style]
+		fmt.Fprintf(b, "  %s%s\"%s\"%s\n", id, shape[0], mermaidText(n.Name), shape[1])
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(b, "  %s -->|\"%s\"| %s\n", ids[e.From], mermaidText(e.Value), ids[e.To])
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func dotID(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func mermaidText(s string) string {
+	return strings.Replace(s, `"`, `#quot;`, -1)
+}
@@ -0,0 +1,275 @@
+package workflow
+
+import (
+	"sort"
+
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+type (
+	// A Graph is the data flow graph of the steps of a workflow. There is an edge from a step that returns a
+	// value to each step that takes that value as a parameter. The steps of a stage only depend on the
+	// parameters of the workflow and on the values returned by the steps of earlier stages.
+	Graph struct {
+		Name   string     `json:"name"`
+		Nodes  []*Node    `json:"nodes"`
+		Edges  []*Edge    `json:"edges"`
+		Stages [][]string `json:"stages"`
+	}
+
+	// A Node is a step of a workflow
+	Node struct {
+		Name  string `json:"name"`
+		Style string `json:"style"`
+
+		// Unsatisfied are the names of the parameters of the step that are neither parameters of the
+		// workflow nor returned by another step
+		Unsatisfied []string `json:"unsatisfied,omitempty"`
+
+		step  *parser.StepExpression
+		index int
+		stage int
+	}
+
+	// An Edge is a value that is returned by one step and consumed by another
+	Edge struct {
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Value string `json:"value"`
+	}
+
+	// An Output is a value that a step exposes to the other steps of its workflow
+	Output struct {
+		Name string
+
+		// Expression is the declaration of the value
+		Expression parser.Expression
+	}
+)
+
+// Graphs returns the graphs of all workflows in the given expression, including nested workflows, in the
+// order that they appear in the source
+func Graphs(e parser.Expression) []*Graph {
+	graphs := make([]*Graph, 0)
+	add := func(e parser.Expression) {
+		if step, ok := e.(*parser.StepExpression); ok && step.Style() == parser.StepStyleWorkflow {
+			graphs = append(graphs, NewGraph(step))
+		}
+	}
+	add(e)
+	e.AllContents(nil, func(path []parser.Expression, e parser.Expression) { add(e) })
+	return graphs
+}
+
+// NewGraph returns the data flow graph of the steps of the given workflow
+func NewGraph(workflow *parser.StepExpression) *Graph {
+	g := &Graph{Name: workflow.Name(), Nodes: make([]*Node, 0), Edges: make([]*Edge, 0)}
+
+	producers := make(map[string]*Node)
+	for i, step := range workflow.Steps() {
+		n := &Node{Name: step.Name(), Style: string(step.Style()), step: step, index: i, stage: -1}
+		g.Nodes = append(g.Nodes, n)
+		for _, o := range Outputs(step) {
+			if _, found := producers[o.Name]; !found {
+				producers[o.Name] = n
+			}
+		}
+	}
+
+	available := Available(workflow)
+
+	for _, n := range g.Nodes {
+		for _, p := range Inputs(n.step) {
+			if available[p.Name()] {
+				continue
+			}
+			if producer, found := producers[p.Name()]; found && producer != n {
+				g.Edges = append(g.Edges, &Edge{From: producer.Name, To: n.Name, Value: p.Name()})
+			} else {
+				n.Unsatisfied = append(n.Unsatisfied, p.Name())
+			}
+		}
+	}
+	g.computeStages()
+	return g
+}
+
+// Inputs returns the parameters of the given step that must be provided by the enclosing workflow. Those are
+// the parameters that have no default value and the parameters of the iteration of the step. The variables
+// of the iteration are not inputs. They must not be declared as parameters of the step.
+func Inputs(step *parser.StepExpression) []*parser.Parameter {
+	inputs := make([]*parser.Parameter, 0)
+	for _, e := range step.Parameters() {
+		if p, ok := e.(*parser.Parameter); ok && p.Value() == nil {
+			inputs = append(inputs, p)
+		}
+	}
+	for _, e := range step.IterationParameters() {
+		if p, ok := e.(*parser.Parameter); ok {
+			inputs = append(inputs, p)
+		}
+	}
+	return inputs
+}
+
+// Outputs returns the values that the given step exposes to the other steps of its workflow. Those are the
+// returns of the step unless the step is iterated, in which case the returns of all iterations are exposed
+// as one value named by the iteration.
+func Outputs(step *parser.StepExpression) []Output {
+	outputs := make([]Output, 0)
+	if step.IterationFunction() != `` {
+		for _, e := range step.Returns() {
+			if _, ok := e.(*parser.Parameter); ok {
+				return append(outputs, Output{Name: step.IterationName(), Expression: step.Property(`iteration`)})
+			}
+		}
+		return outputs
+	}
+	for _, e := range step.Returns() {
+		if p, ok := e.(*parser.Parameter); ok {
+			outputs = append(outputs, Output{Name: p.Name(), Expression: p})
+		}
+	}
+	return outputs
+}
+
+// Available returns the names of the values that the given workflow provides to its steps. Those are the
+// parameters of the workflow and the variables of its iteration.
+func Available(workflow *parser.StepExpression) map[string]bool {
+	available := make(map[string]bool)
+	for _, params := range [][]parser.Expression{workflow.Parameters(), workflow.IterationVariables()} {
+		for _, e := range params {
+			if p, ok := e.(*parser.Parameter); ok {
+				available[p.Name()] = true
+			}
+		}
+	}
+	return available
+}
+
+// Step returns the step that the node represents
+func (n *Node) Step() *parser.StepExpression {
+	return n.step
+}
+
+// Node returns the node with the given name or nil if no such node exists
+func (g *Graph) Node(name string) *Node {
+	for _, n := range g.Nodes {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// Cycles returns the groups of steps that depend on each other. The groups and the steps of each group are
+// in the order of declaration.
+func (g *Graph) Cycles() [][]*Node {
+	// Tarjan's strongly connected components algorithm
+	index := 0
+	indexes := make(map[*Node]int)
+	lowLinks := make(map[*Node]int)
+	onStack := make(map[*Node]bool)
+	stack := make([]*Node, 0)
+	cycles := make([][]*Node, 0)
+
+	var connect func(n *Node)
+	connect = func(n *Node) {
+		indexes[n] = index
+		lowLinks[n] = index
+		index++
+		stack = append(stack, n)
+		onStack[n] = true
+		for _, s := range g.successors(n) {
+			if _, visited := indexes[s]; !visited {
+				connect(s)
+				if lowLinks[s] < lowLinks[n] {
+					lowLinks[n] = lowLinks[s]
+				}
+			} else if onStack[s] && indexes[s] < lowLinks[n] {
+				lowLinks[n] = indexes[s]
+			}
+		}
+		if lowLinks[n] == indexes[n] {
+			component := make([]*Node, 0)
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == n {
+					break
+				}
+			}
+			if len(component) > 1 {
+				sort.Slice(component, func(i, j int) bool { return component[i].index < component[j].index })
+				cycles = append(cycles, component)
+			}
+		}
+	}
+	for _, n := range g.Nodes {
+		if _, visited := indexes[n]; !visited {
+			connect(n)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0].index < cycles[j][0].index })
+	return cycles
+}
+
+// Unreachable returns the steps that can never run because they depend on a value that is never produced,
+// either directly or through the steps that they depend on, or because they are part of a cycle
+func (g *Graph) Unreachable() []*Node {
+	unreachable := make([]*Node, 0)
+	for _, n := range g.Nodes {
+		if n.stage < 0 {
+			unreachable = append(unreachable, n)
+		}
+	}
+	return unreachable
+}
+
+// computeStages assigns each step that can run to the first stage after the stages of the steps that it
+// depends on
+func (g *Graph) computeStages() {
+	g.Stages = make([][]string, 0)
+	for {
+		stage := make([]*Node, 0)
+		for _, n := range g.Nodes {
+			if n.stage < 0 && len(n.Unsatisfied) == 0 && g.isRunnable(n, len(g.Stages)) {
+				stage = append(stage, n)
+			}
+		}
+		if len(stage) == 0 {
+			return
+		}
+		names := make([]string, len(stage))
+		for i, n := range stage {
+			n.stage = len(g.Stages)
+			names[i] = n.Name
+		}
+		g.Stages = append(g.Stages, names)
+	}
+}
+
+// isRunnable returns true if all steps that the given node depends on have been assigned to a stage that
+// is before the given stage
+func (g *Graph) isRunnable(n *Node, stage int) bool {
+	for _, e := range g.Edges {
+		if e.To == n.Name {
+			if from := g.Node(e.From); from.stage < 0 || from.stage >= stage {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (g *Graph) successors(n *Node) []*Node {
+	successors := make([]*Node, 0)
+	for _, e := range g.Edges {
+		if e.From == n.Name {
+			successors = append(successors, g.Node(e.To))
+		}
+	}
+	return successors
+}
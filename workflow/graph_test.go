@@ -0,0 +1,211 @@
+package workflow
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/json"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+var attach = issue.Unindent(`
+  workflow attach {
+    parameters => (String $region),
+    returns => ($vpc_id, $subnet_id)
+  } {
+    resource vpc {
+      parameters => ($region),
+      returns => ($vpc_id = vpcId),
+      type => Aws::Vpc
+    } {
+      region => $region
+    }
+    resource subnet {
+      parameters => ($region, $vpc_id),
+      returns => ($subnet_id = subnetId),
+      type => Aws::Subnet
+    } {
+      region => $region,
+      vpc_id => $vpc_id
+    }
+    action notify {
+      parameters => ($vpc_id, $subnet_id)
+    } {}
+  }`)
+
+func TestNewGraph(t *testing.T) {
+	g := parseGraphs(t, attach)[0]
+	expectEdges(t, g, []*Edge{
+		{From: `attach::vpc`, To: `attach::subnet`, Value: `vpc_id`},
+		{From: `attach::vpc`, To: `attach::notify`, Value: `vpc_id`},
+		{From: `attach::subnet`, To: `attach::notify`, Value: `subnet_id`}})
+
+	expected := [][]string{{`attach::vpc`}, {`attach::subnet`}, {`attach::notify`}}
+	if !reflect.DeepEqual(g.Stages, expected) {
+		t.Errorf(`expected stages %v, got %v`, expected, g.Stages)
+	}
+	if len(g.Cycles()) != 0 || len(g.Unreachable()) != 0 {
+		t.Errorf(`expected no cycles and no unreachable steps`)
+	}
+}
+
+func TestGraphIteration(t *testing.T) {
+	g := parseGraphs(t, issue.Unindent(`
+    workflow foo {
+      parameters => ($list)
+    } {
+      action prepare {
+        returns => ($names)
+      } {}
+      resource bar {
+        returns => ($id)
+      } $ids = each($names) |$name| {
+        name => $name
+      }
+      action report {
+        parameters => ($ids)
+      } {}
+    }`))[0]
+	expectEdges(t, g, []*Edge{
+		{From: `foo::prepare`, To: `foo::bar`, Value: `names`},
+		{From: `foo::bar`, To: `foo::report`, Value: `ids`}})
+
+	outputs := Outputs(g.Node(`foo::bar`).Step())
+	if len(outputs) != 1 || outputs[0].Name != `ids` {
+		t.Errorf(`expected the returns of 'foo::bar' to be exposed as 'ids', got %v`, outputs)
+	}
+}
+
+func TestGraphCycles(t *testing.T) {
+	g := parseGraphs(t, issue.Unindent(`
+    workflow loop {
+      parameters => ($a)
+    } {
+      action one {
+        parameters => ($a, $c),
+        returns => ($b)
+      } {}
+      action two {
+        parameters => ($b),
+        returns => ($c, $d)
+      } {}
+      action three {
+        parameters => ($d)
+      } {}
+      action four {
+        parameters => ($e)
+      } {}
+      action five {
+        parameters => ($a)
+      } {}
+    }`))[0]
+
+	cycles := g.Cycles()
+	if len(cycles) != 1 || len(cycles[0]) != 2 || cycles[0][0].Name != `loop::one` || cycles[0][1].Name != `loop::two` {
+		t.Errorf(`expected one cycle of 'loop::one' and 'loop::two', got %v`, cycles)
+	}
+
+	names := make([]string, 0)
+	for _, n := range g.Unreachable() {
+		names = append(names, n.Name)
+	}
+	expected := []string{`loop::one`, `loop::two`, `loop::three`, `loop::four`}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf(`expected unreachable steps %v, got %v`, expected, names)
+	}
+	if u := g.Node(`loop::four`).Unsatisfied; !reflect.DeepEqual(u, []string{`e`}) {
+		t.Errorf(`expected 'e' to be unsatisfied, got %v`, u)
+	}
+	if !reflect.DeepEqual(g.Stages, [][]string{{`loop::five`}}) {
+		t.Errorf(`expected one stage with 'loop::five', got %v`, g.Stages)
+	}
+}
+
+func TestGraphs(t *testing.T) {
+	graphs := parseGraphs(t, issue.Unindent(`
+    workflow outer {} {
+      workflow inner {} {
+        action a {} {}
+      }
+    }`))
+	if len(graphs) != 2 || graphs[0].Name != `outer` || graphs[1].Name != `outer::inner` {
+		t.Errorf(`expected graphs of 'outer' and 'outer::inner'`)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	if err := parseGraphs(t, attach)[0].WriteDOT(b); err != nil {
+		t.Fatal(err)
+	}
+	expectOutput(t, b, `digraph "attach" {
+  rankdir=LR;
+  "attach::vpc" [label="attach::vpc", shape=ellipse];
+  "attach::subnet" [label="attach::subnet", shape=ellipse];
+  "attach::notify" [label="attach::notify", shape=box];
+  "attach::vpc" -> "attach::subnet" [label="vpc_id"];
+  "attach::vpc" -> "attach::notify" [label="vpc_id"];
+  "attach::subnet" -> "attach::notify" [label="subnet_id"];
+}
+`)
+}
+
+func TestWriteMermaid(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	if err := parseGraphs(t, attach)[0].WriteMermaid(b); err != nil {
+		t.Fatal(err)
+	}
+	expectOutput(t, b, `---
+title: attach
+---
+flowchart LR
+  n0(["attach::vpc"])
+  n1(["attach::subnet"])
+  n2["attach::notify"]
+  n0 -->|"vpc_id"| n1
+  n0 -->|"vpc_id"| n2
+  n1 -->|"subnet_id"| n2
+`)
+}
+
+func TestGraphJSON(t *testing.T) {
+	b := bytes.NewBufferString(``)
+	json.ToJson(parseGraphs(t, attach)[0], b)
+	expectOutput(t, b, `{"name":"attach","nodes":[`+
+		`{"name":"attach::vpc","style":"resource"},{"name":"attach::subnet","style":"resource"},{"name":"attach::notify","style":"action"}],`+
+		`"edges":[{"from":"attach::vpc","to":"attach::subnet","value":"vpc_id"},{"from":"attach::vpc","to":"attach::notify","value":"vpc_id"},`+
+		`{"from":"attach::subnet","to":"attach::notify","value":"subnet_id"}],`+
+		`"stages":[["attach::vpc"],["attach::subnet"],["attach::notify"]]}`+"\n")
+}
+
+func parseGraphs(t *testing.T, source string) []*Graph {
+	t.Helper()
+	return Graphs(parse(t, source))
+}
+
+func parse(t *testing.T, source string) parser.Expression {
+	t.Helper()
+	expr, err := parser.CreateParser(parser.WorkflowEnabled).Parse(``, source, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return expr
+}
+
+func expectEdges(t *testing.T, g *Graph, expected []*Edge) {
+	t.Helper()
+	if !reflect.DeepEqual(g.Edges, expected) {
+		b := bytes.NewBufferString(``)
+		json.ToJson(g.Edges, b)
+		t.Errorf(`unexpected edges %s`, b.String())
+	}
+}
+
+func expectOutput(t *testing.T, b *bytes.Buffer, expected string) {
+	t.Helper()
+	if s := b.String(); s != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, s)
+	}
+}
@@ -0,0 +1,16 @@
+package workflow
+
+import (
+	"github.com/lyraproj/issue/issue"
+)
+
+const (
+	WorkflowDuplicateStep = `WORKFLOW_DUPLICATE_STEP`
+	WorkflowGoNameClash   = `WORKFLOW_GO_NAME_CLASH`
+)
+
+func init() {
+	issue.Hard(WorkflowDuplicateStep, `The %{style} '%{name}' is also declared in %{file}`)
+
+	issue.Hard(WorkflowGoNameClash, `The Go name %{goName} of '%{name}' is also the Go name of '%{other}'`)
+}
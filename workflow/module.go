@@ -0,0 +1,69 @@
+package workflow
+
+import (
+	"sort"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/module"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// A Module is the set of steps declared in the .pp files of a directory and its subdirectories. A step of
+// one file can call a step of another file using the qualified name of that step.
+type Module struct {
+	*module.Sources
+	steps map[string]*parser.StepExpression
+}
+
+// LoadModule parses all .pp files in the given directory and its subdirectories with workflows enabled. An
+// error is returned if a file cannot be read or parsed, or if a step is declared in more than one place.
+func LoadModule(dir string) (*Module, error) {
+	sources, err := module.Load(dir, parser.WorkflowEnabled)
+	if err != nil {
+		return nil, err
+	}
+	return NewModule(sources)
+}
+
+// NewModule returns the module of the given sources. An error is returned if a step is declared in more than
+// one place.
+func NewModule(sources *module.Sources) (*Module, error) {
+	m := &Module{Sources: sources, steps: make(map[string]*parser.StepExpression)}
+	for _, file := range m.Files() {
+		if err := m.addSteps(m.Expression(file)); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Step returns the step with the given qualified name or nil if no such step is declared in the module
+func (m *Module) Step(name string) *parser.StepExpression {
+	return m.steps[name]
+}
+
+// StepNames returns the sorted qualified names of all steps declared in the module
+func (m *Module) StepNames() []string {
+	names := make([]string, 0, len(m.steps))
+	for name := range m.steps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Module) addSteps(expr parser.Expression) (err error) {
+	expr.AllContents(nil, func(path []parser.Expression, e parser.Expression) {
+		step, ok := e.(*parser.StepExpression)
+		if !ok || err != nil {
+			return
+		}
+		if other, found := m.steps[step.Name()]; found {
+			err = issue.NewReported(WorkflowDuplicateStep, issue.SeverityError,
+				issue.H{`style`: step.Style(), `name`: step.Name(), `file`: other.File()}, step)
+			return
+		}
+		m.steps[step.Name()] = step
+	})
+	return
+}
@@ -0,0 +1,60 @@
+package workflow
+
+import (
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/module"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestNewModule(t *testing.T) {
+	m, err := parseModule(t, map[string]string{
+		`attach.pp`: `
+      workflow attach {} {
+        workflow network {
+          call => network::setup
+        }
+      }`,
+		`network/setup.pp`: `
+      workflow network::setup {} {
+        action create {} {}
+      }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{`attach`, `attach::network`, `network::setup`, `network::setup::create`} {
+		if m.Step(name) == nil {
+			t.Errorf(`expected step '%s' to be found`, name)
+		}
+	}
+	if call := m.Step(`attach::network`).Call(); call != `network::setup` {
+		t.Errorf(`expected 'attach::network' to call 'network::setup', got '%s'`, call)
+	}
+	if m.Step(`create`) != nil {
+		t.Errorf(`expected step 'create' to not be found`)
+	}
+}
+
+func TestNewModuleErrors(t *testing.T) {
+	_, err := parseModule(t, map[string]string{
+		`a.pp`: `workflow a {} { action x {} {} }`,
+		`b.pp`: `workflow a {} {}`})
+	if i, ok := err.(issue.Reported); !ok || i.Code() != WorkflowDuplicateStep {
+		t.Errorf(`expected %s, got %v`, WorkflowDuplicateStep, err)
+	}
+}
+
+// parseModule returns the module of the given sources, keyed by file name
+func parseModule(t *testing.T, files map[string]string) (*Module, error) {
+	t.Helper()
+	exprs := make(map[string]parser.Expression, len(files))
+	for file, source := range files {
+		expr, err := parser.CreateParser(parser.WorkflowEnabled).Parse(file, issue.Unindent(source), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		exprs[file] = expr
+	}
+	return NewModule(module.New(exprs))
+}
@@ -0,0 +1,224 @@
+package workflow
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+// Go types of the Puppet types that have no type parameters or whose type parameters don't affect the Go type
+var goTypes = map[string]string{
+	`Boolean`:   `bool`,
+	`Enum`:      `string`,
+	`Float`:     `float64`,
+	`Integer`:   `int64`,
+	`Numeric`:   `float64`,
+	`Pattern`:   `string`,
+	`String`:    `string`,
+	`Array`:     `[]interface{}`,
+	`Hash`:      `map[string]interface{}`,
+	`Struct`:    `map[string]interface{}`,
+	`Tuple`:     `[]interface{}`,
+	`Undef`:     `interface{}`,
+	`Any`:       `interface{}`,
+	`Data`:      `interface{}`,
+	`Scalar`:    `interface{}`,
+	`Variant`:   `interface{}`,
+	`Sensitive`: `interface{}`,
+}
+
+// Initialisms that golint expects to have a consistent case in Go names
+var commonInitialisms = map[string]bool{
+	`ACL`: true, `API`: true, `ASCII`: true, `CPU`: true, `CSS`: true, `DNS`: true, `EOF`: true, `GUID`: true,
+	`HTML`: true, `HTTP`: true, `HTTPS`: true, `ID`: true, `IP`: true, `JSON`: true, `LHS`: true, `QPS`: true,
+	`RAM`: true, `RHS`: true, `RPC`: true, `SLA`: true, `SMTP`: true, `SQL`: true, `SSH`: true, `TCP`: true,
+	`TLS`: true, `TTL`: true, `UDP`: true, `UI`: true, `UID`: true, `UUID`: true, `URI`: true, `URL`: true,
+	`UTF8`: true, `VM`: true, `XML`: true, `XMPP`: true, `XSRF`: true, `XSS`: true,
+}
+
+// ScaffoldSteps returns the actions and workflows in the given expression, including nested ones, in the
+// order that they appear in the source
+func ScaffoldSteps(e parser.Expression) []*parser.StepExpression {
+	steps := make([]*parser.StepExpression, 0)
+	add := func(e parser.Expression) {
+		if step, ok := e.(*parser.StepExpression); ok && (step.Style() == parser.StepStyleAction || step.Style() == parser.StepStyleWorkflow) {
+			steps = append(steps, step)
+		}
+	}
+	add(e)
+	e.AllContents(nil, func(path []parser.Expression, e parser.Expression) { add(e) })
+	return steps
+}
+
+// WriteGoStubs writes a Go source file of the given package with a handler stub for each of the given steps.
+// The handler of a step takes a struct with a field for each parameter of the step and returns a struct with
+// a field for each of its returns. The fields are tagged with the names of the parameters and returns. An
+// error is returned when two steps, or two parameters or returns of a step, get the same Go name.
+func WriteGoStubs(steps []*parser.StepExpression, pkg string, w io.Writer) error {
+	b := bytes.NewBufferString(``)
+	fmt.Fprintf(b, "package %s\n", pkg)
+	names := make(map[string]string, len(steps)*3)
+	for _, step := range steps {
+		name := goName(step.Name())
+		for _, id := range []string{name, name + `Parameters`, name + `Returns`} {
+			if err := claimGoName(names, id, step.Name(), step); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintf(b, "\n// %sParameters are the parameters of the %s '%s'\n", name, step.Style(), step.Name())
+		if err := writeGoStruct(b, name+`Parameters`, step.Parameters()); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "\n// %sReturns are the returns of the %s '%s'\n", name, step.Style(), step.Name())
+		if err := writeGoStruct(b, name+`Returns`, step.Returns()); err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "\n// %s implements the %s '%s'\n", name, step.Style(), step.Name())
+		fmt.Fprintf(b, "func %s(params *%sParameters) (*%sReturns, error) {\n", name, name, name)
+		fmt.Fprintf(b, "\t// TODO: implement the %s\n\treturn &%sReturns{}, nil\n}\n", step.Style(), name)
+	}
+	src, err := format.Source(b.Bytes())
+	if err == nil {
+		_, err = w.Write(src)
+	}
+	return err
+}
+
+// WriteFixtures writes a workflow for each of the given steps that calls the step with all of its parameters
+// and returns all of its returns. The workflow is named after the step with a '_test' suffix.
+func WriteFixtures(steps []*parser.StepExpression, w io.Writer) error {
+	b := bytes.NewBufferString(``)
+	for i, step := range steps {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		params := declarationList(step.Parameters(), step.IterationParameters())
+		returns := declarationList(step.Returns())
+		name := step.Name()[strings.LastIndex(step.Name(), `:`)+1:]
+
+		fmt.Fprintf(b, "# Test fixture for the %s '%s'\n", step.Style(), step.Name())
+		fmt.Fprintf(b, "workflow %s_test {\n  parameters => (%s),\n  returns => (%s)\n} {\n", step.Name(), params, returns)
+		fmt.Fprintf(b, "  %s %s {\n    call => %s,\n    parameters => (%s),\n    returns => (%s)\n  }", step.Style(), name, step.Name(), params, returns)
+		if step.Style() != parser.StepStyleWorkflow {
+			b.WriteString(" {}")
+		}
+		b.WriteString("\n}\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// GoType returns the Go type of the given Puppet type expression. A type that is optional is a pointer unless
+// it is a slice, a map, or an interface. The type is interface{} when the given expression is nil or when the
+// Puppet type has no Go equivalent.
+func GoType(t parser.Expression) string {
+	var name string
+	var args []parser.Expression
+	switch t := t.(type) {
+	case *parser.QualifiedReference:
+		name = t.Name()
+	case *parser.AccessExpression:
+		qr, ok := t.Operand().(*parser.QualifiedReference)
+		if !ok {
+			return `interface{}`
+		}
+		name = qr.Name()
+		args = t.Keys()
+	default:
+		return `interface{}`
+	}
+
+	switch {
+	case name == `Optional` && len(args) == 1:
+		gt := GoType(args[0])
+		if strings.HasPrefix(gt, `[]`) || strings.HasPrefix(gt, `map[`) || gt == `interface{}` {
+			return gt
+		}
+		return `*` + gt
+	case name == `Sensitive` && len(args) == 1:
+		return GoType(args[0])
+	case name == `Array` && len(args) > 0 && isType(args[0]):
+		return `[]` + GoType(args[0])
+	case name == `Hash` && len(args) > 1 && isType(args[0]) && isType(args[1]):
+		return `map[` + GoType(args[0]) + `]` + GoType(args[1])
+	}
+	if gt, ok := goTypes[name]; ok {
+		return gt
+	}
+	return `interface{}`
+}
+
+func writeGoStruct(b *bytes.Buffer, name string, decls []parser.Expression) error {
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	names := make(map[string]string, len(decls))
+	for _, e := range decls {
+		if p, ok := e.(*parser.Parameter); ok {
+			field := goName(p.Name())
+			if err := claimGoName(names, field, p.Name(), p); err != nil {
+				return err
+			}
+			fmt.Fprintf(b, "\t%s %s `json:\"%s\"`\n", field, GoType(p.Type()), p.Name())
+		}
+	}
+	b.WriteString("}\n")
+	return nil
+}
+
+// goName returns the exported Go identifier of a Puppet name, e.g. 'AttachVpcID' for 'attach::vpc_id'. Segments
+// that are common initialisms, such as 'id' or 'url', are written in upper case as recommended by golint.
+func goName(name string) string {
+	b := bytes.NewBufferString(``)
+	for _, segment := range strings.FieldsFunc(name, func(c rune) bool { return c == ':' || c == '_' }) {
+		if upper := strings.ToUpper(segment); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(strings.ToUpper(segment[:1]))
+		b.WriteString(segment[1:])
+	}
+	return b.String()
+}
+
+// claimGoName records that the given Go name is used for the given Puppet name. An error is returned when
+// the Go name is already used for another Puppet name.
+func claimGoName(names map[string]string, goName, name string, location issue.Location) error {
+	if other, ok := names[goName]; ok {
+		return issue.NewReported(WorkflowGoNameClash, issue.SeverityError, issue.H{`goName`: goName, `name`: name, `other`: other}, location)
+	}
+	names[goName] = name
+	return nil
+}
+
+// declarationList returns the condensed declarations of the given parameters without their values
+func declarationList(declLists ...[]parser.Expression) string {
+	strs := make([]string, 0)
+	for _, decls := range declLists {
+		for _, e := range decls {
+			if p, ok := e.(*parser.Parameter); ok {
+				if p.Type() != nil {
+					strs = append(strs, p.Type().String()+` $`+p.Name())
+				} else {
+					strs = append(strs, `$`+p.Name())
+				}
+			}
+		}
+	}
+	return strings.Join(strs, `, `)
+}
+
+// isType returns true if the given expression is a type reference, as opposed to a size constraint
+func isType(e parser.Expression) bool {
+	switch e := e.(type) {
+	case *parser.QualifiedReference:
+		return true
+	case *parser.AccessExpression:
+		_, ok := e.Operand().(*parser.QualifiedReference)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package workflow
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lyraproj/issue/issue"
+	"github.com/lyraproj/puppet-parser/parser"
+)
+
+func TestGoType(t *testing.T) {
+	for source, expected := range map[string]string{
+		`String`:                             `string`,
+		`Enum[a, b]`:                         `string`,
+		`Integer[0]`:                         `int64`,
+		`Float`:                              `float64`,
+		`Boolean`:                            `bool`,
+		`Optional[String]`:                   `*string`,
+		`Optional[Array[Integer]]`:           `[]int64`,
+		`Optional[Any]`:                      `interface{}`,
+		`Array`:                              `[]interface{}`,
+		`Array[String, 1]`:                   `[]string`,
+		`Array[1, 2]`:                        `[]interface{}`,
+		`Hash[String, String]`:               `map[string]string`,
+		`Hash[String, Optional[Integer], 1]`: `map[string]*int64`,
+		`Hash`:                               `map[string]interface{}`,
+		`Sensitive[String]`:                  `string`,
+		`Variant[String, Integer]`:           `interface{}`,
+		`My::Type`:                           `interface{}`,
+	} {
+		expr, err := parser.CreateParser().Parse(``, source, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := expr.(*parser.Program).Body()
+		if block, ok := body.(*parser.BlockExpression); ok {
+			body = block.Statements()[0]
+		}
+		if actual := GoType(body); actual != expected {
+			t.Errorf(`expected %s to be %s, got %s`, source, expected, actual)
+		}
+	}
+	if actual := GoType(nil); actual != `interface{}` {
+		t.Errorf(`expected no type to be interface{}, got %s`, actual)
+	}
+}
+
+var scaffolded = issue.Unindent(`
+  workflow attach {
+    parameters => (String $region),
+    returns => ($vpc_id)
+  } {
+    action create_vpc {
+      parameters => (String $region, Optional[Integer] $size = 16),
+      returns => (String $vpc_id)
+    } {}
+    resource subnet {
+      parameters => ($vpc_id),
+      type => Aws::Subnet
+    } {}
+  }`)
+
+func TestWriteGoStubs(t *testing.T) {
+	steps := ScaffoldSteps(parse(t, scaffolded))
+	b := bytes.NewBufferString(``)
+	if err := WriteGoStubs(steps[1:], `handlers`, b); err != nil {
+		t.Fatal(err)
+	}
+	expectOutput(t, b, "package handlers\n"+
+		"\n"+
+		"// AttachCreateVpcParameters are the parameters of the action 'attach::create_vpc'\n"+
+		"type AttachCreateVpcParameters struct {\n"+
+		"\tRegion string `json:\"region\"`\n"+
+		"\tSize   *int64 `json:\"size\"`\n"+
+		"}\n"+
+		"\n"+
+		"// AttachCreateVpcReturns are the returns of the action 'attach::create_vpc'\n"+
+		"type AttachCreateVpcReturns struct {\n"+
+		"\tVpcID string `json:\"vpc_id\"`\n"+
+		"}\n"+
+		"\n"+
+		"// AttachCreateVpc implements the action 'attach::create_vpc'\n"+
+		"func AttachCreateVpc(params *AttachCreateVpcParameters) (*AttachCreateVpcReturns, error) {\n"+
+		"\t// TODO: implement the action\n"+
+		"\treturn &AttachCreateVpcReturns{}, nil\n"+
+		"}\n")
+}
+
+func TestGoName(t *testing.T) {
+	for name, expected := range map[string]string{
+		`attach::vpc_id`: `AttachVpcID`,
+		`http_url`:       `HTTPURL`,
+		`json_api::ttl`:  `JSONAPITTL`,
+		`identity`:       `Identity`,
+	} {
+		if gn := goName(name); gn != expected {
+			t.Errorf(`expected the Go name of '%s' to be %s, got %s`, name, expected, gn)
+		}
+	}
+}
+
+func TestWriteGoStubsNameClash(t *testing.T) {
+	for _, source := range []string{
+		issue.Unindent(`
+      workflow foo {} {
+        action bar_baz {} {}
+      }
+      workflow foo_bar {} {
+        action baz {} {}
+      }`),
+		issue.Unindent(`
+      workflow foo {} {
+        action bar {} {}
+        action bar_returns {} {}
+      }`),
+		issue.Unindent(`
+      action foo {
+        parameters => (String $http_url, String $http__url)
+      } {}`),
+	} {
+		err := WriteGoStubs(ScaffoldSteps(parse(t, source)), `handlers`, bytes.NewBufferString(``))
+		if ri, ok := err.(issue.Reported); !ok || ri.Code() != WorkflowGoNameClash {
+			t.Errorf(`expected %s, got %v`, WorkflowGoNameClash, err)
+		}
+	}
+}
+
+func TestWriteFixtures(t *testing.T) {
+	steps := ScaffoldSteps(parse(t, scaffolded))
+	if len(steps) != 2 || steps[0].Name() != `attach` || steps[1].Name() != `attach::create_vpc` {
+		t.Fatalf(`expected the workflow 'attach' and the action 'attach::create_vpc'`)
+	}
+	b := bytes.NewBufferString(``)
+	if err := WriteFixtures(steps, b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# Test fixture for the workflow 'attach'
+workflow attach_test {
+  parameters => (String $region),
+  returns => ($vpc_id)
+} {
+  workflow attach {
+    call => attach,
+    parameters => (String $region),
+    returns => ($vpc_id)
+  }
+}
+
+# Test fixture for the action 'attach::create_vpc'
+workflow attach::create_vpc_test {
+  parameters => (String $region, Optional[Integer] $size),
+  returns => (String $vpc_id)
+} {
+  action create_vpc {
+    call => attach::create_vpc,
+    parameters => (String $region, Optional[Integer] $size),
+    returns => (String $vpc_id)
+  } {}
+}
+`
+	expectOutput(t, b, expected)
+
+	// The fixtures must be valid workflows
+	if fixtures := Descriptors(parse(t, b.String())); len(fixtures) != 2 || fixtures[1].Steps[0].Name != `attach::create_vpc_test::create_vpc` {
+		t.Errorf(`expected the fixtures to parse`)
+	}
+}